@@ -3,6 +3,7 @@ package sashay
 import (
 	"fmt"
 	"io"
+	"mime/multipart"
 	"reflect"
 	"sort"
 	"strings"
@@ -11,6 +12,9 @@ import (
 type baseBuilder struct {
 	buf     io.Writer
 	swagger *Sashay
+	// requestBodyContentTypes are the content types of components/requestBodies by body type,
+	// see Sashay.componentRequestContentTypes.
+	requestBodyContentTypes map[reflect.Type]string
 }
 
 func (b *baseBuilder) writeLn(indent int, format string, i ...interface{}) {
@@ -38,7 +42,21 @@ func (b *baseBuilder) writeNotEmpty(indent int, format string, s string) {
 	}
 }
 
-func (b *baseBuilder) writeDataType(indent int, f Field) {
+// Write the externalDocs at indent, if it is set.
+// Panics if it has a Description but no URL, since the URL is required.
+func (b *baseBuilder) writeExternalDocs(indent int, docs ExternalDocs) {
+	if docs == (ExternalDocs{}) {
+		return
+	}
+	if docs.URL == "" {
+		panic(fmt.Sprintf("externalDocs %q must have a URL", docs.Description))
+	}
+	b.writeLn(indent, "externalDocs:")
+	b.writeLn(indent+1, "url: %s", docs.URL)
+	b.writeNotEmpty(indent+1, "description: %s", docs.Description)
+}
+
+func (b *baseBuilder) writeDataType(indent int, f Field) ObjectFields {
 	dataTypeDef, found := b.swagger.dataTypeDefFor(f)
 	if !found {
 		ts := "(no type)"
@@ -55,26 +73,37 @@ func (b *baseBuilder) writeDataType(indent int, f Field) {
 	for _, kv := range objectFields.Sorted() {
 		b.writeLn(indent, "%s: %s", kv[0], kv[1])
 	}
+	return objectFields
 }
 
 // Write struct f and all its fields recursively.
 // If recurse returns true for a struct field, call writeStructSchema on it.
 // If it doesn't, write the field as concrete ($ref for data type).
 func (b *baseBuilder) writeStructSchema(indent int, f Field, recurse func(Field) bool) {
+	b.writeNamedStructSchema(indent, f, jsonName, recurse)
+}
+
+// Like writeStructSchema, but the property names of f's fields come from nameOf
+// (like jsonName or formName). Nested structs always use JSON names.
+func (b *baseBuilder) writeNamedStructSchema(
+	indent int, f Field, nameOf func(reflect.StructField) string, recurse func(Field) bool) {
+
 	b.writeLn(indent, "type: object")
 	writeProps := b.writeOnce(indent, "properties:")
 	for _, field := range enumerateStructFields(f) {
-		fieldJSONName := jsonName(field.StructField)
+		fieldJSONName := nameOf(field.StructField)
 		if fieldJSONName == "" {
 			continue
 		}
 		writeProps()
+		isRef := false
 		if field.Kind == reflect.Struct {
 			b.writeLn(indent+1, "%s:", fieldJSONName)
 			if recurse(field) {
 				b.writeStructSchema(indent+2, field, recurse)
 			} else {
 				b.writeRefSchema(indent+2, field)
+				isRef = b.isSchemaRef(field)
 			}
 		} else if field.Kind == reflect.Slice {
 			b.writeLn(indent+1, "%s:", fieldJSONName)
@@ -94,9 +123,20 @@ func (b *baseBuilder) writeStructSchema(indent int, f Field, recurse func(Field)
 			b.writeLn(indent+1, "%s:", fieldJSONName)
 			b.writeDataType(indent+2, field)
 		}
+		// Siblings of a $ref are ignored, so extensions are only written next to other schemas.
+		if !isRef {
+			b.writeTagExtensions(indent+2, field)
+		}
 	}
 }
 
+// Return true if writeRefSchema writes f as a $ref to its schema,
+// rather than as an empty object or a data type.
+func (b *baseBuilder) isSchemaRef(f Field) bool {
+	isEmptyStruct := f.Type.NumField() == 0
+	return f.Kind == reflect.Struct && !isEmptyStruct && !b.swagger.isMappedToDataType(f)
+}
+
 func (b *baseBuilder) writeRefSchema(indent int, f Field) {
 	if f.Kind == reflect.Slice {
 		b.writeLn(indent, "type: array")
@@ -116,6 +156,113 @@ func (b *baseBuilder) writeRefSchema(indent int, f Field) {
 	}
 }
 
+// Write the named examples, if there are any,
+// otherwise the example from the value of f, if it is populated and Sashay.ExamplesFromValues is true.
+func (b *baseBuilder) writeExamples(indent int, f Field, named Examples) {
+	if len(named) > 0 {
+		b.writeLn(indent, "examples:")
+		for _, name := range named.sortedNames() {
+			b.writeLn(indent+1, "%s:", name)
+			b.writeLn(indent+2, "value: %s", exampleJSON(named[name]))
+		}
+		return
+	}
+	if !b.swagger.ExamplesFromValues {
+		return
+	}
+	if example, ok := valueExampleJSON(f); ok {
+		b.writeLn(indent, "example: %s", example)
+	}
+}
+
+// Write the schema for a request body value f, which can be any Go value.
+// Structs are expanded, unless they are mapped to a data type.
+// Slices and maps are written as arrays and objects, with items and additionalProperties.
+// Slice items and map values that are exported named structs are written as a $ref,
+// since they are meant to be shared (like a []User body), otherwise they are expanded.
+// []byte is written as binary data.
+func (b *baseBuilder) writeBodySchema(indent int, f Field) {
+	expand := func(f Field) bool {
+		return !b.swagger.isMappedToDataType(f)
+	}
+	if f.Nil() || f.Kind == reflect.Interface {
+		b.writeLn(indent, "{}")
+		return
+	}
+	if isBytesField(f) {
+		b.writeLn(indent, "type: string")
+		b.writeLn(indent, "format: binary")
+		return
+	}
+	switch f.Kind {
+	case reflect.Struct:
+		if expand(f) {
+			b.writeStructSchema(indent, f, expand)
+		} else {
+			b.writeDataType(indent, f)
+		}
+	case reflect.Slice:
+		if b.writeCollectionType(indent, f, "array") {
+			b.writeBodyItemSchema(indent, "items:", zeroElemField(f.Type))
+		}
+	case reflect.Map:
+		if b.writeCollectionType(indent, f, "object") {
+			if item := zeroElemField(f.Type); !item.Nil() {
+				b.writeBodyItemSchema(indent, "additionalProperties:", item)
+			}
+		}
+	default:
+		b.writeDataType(indent, f)
+	}
+}
+
+// Write the type of the slice or map f, which is swaggerType unless a data type is defined for it.
+// Return true if the schema is (still) of swaggerType, so its items should be written.
+func (b *baseBuilder) writeCollectionType(indent int, f Field, swaggerType string) bool {
+	if _, found := b.swagger.dataTypeDefFor(f); found {
+		return b.writeDataType(indent, f)["type"] == swaggerType
+	}
+	b.writeLn(indent, "type: %s", swaggerType)
+	return true
+}
+
+func (b *baseBuilder) writeBodyItemSchema(indent int, key string, item Field) {
+	if item.Nil() || item.Kind == reflect.Interface {
+		b.writeLn(indent, "%s {}", key)
+		return
+	}
+	b.writeLn(indent, key)
+	if b.isRefStruct(item) {
+		b.writeRefSchema(indent+1, item)
+	} else {
+		b.writeBodySchema(indent+1, item)
+	}
+}
+
+// Return true if the entire request body for op should be written as a $ref to components/requestBodies.
+// This requires the body to be a referenced struct (see refBodyStruct) with a single content type,
+// which is not JSON Patch, and is the content type of the component for the body's type
+// (see componentRequestContentTypes). Other operations with the same body type are written inline.
+func (b *baseBuilder) refRequestBody(op internalOperation) bool {
+	return b.swagger.componentRequestBody(op) &&
+		b.swagger.requestContentType(op) == b.requestBodyContentTypes[op.bodyField().Type]
+}
+
+// Return true if f is a struct that is written into components/schemas and referenced with $ref,
+// rather than expanded (it has an exported name, and fields, and is not mapped to a data type).
+func (b *baseBuilder) isRefStruct(f Field) bool {
+	return f.Kind == reflect.Struct &&
+		f.Type.PkgPath() != "" &&
+		isExportedName(f.Type.Name()) &&
+		f.Type.NumField() > 0 &&
+		!b.swagger.isMappedToDataType(f)
+}
+
+// Return true if f is a []byte, which is represented as binary data rather than an array.
+func isBytesField(f Field) bool {
+	return f.Kind == reflect.Slice && f.Type.Elem().Kind() == reflect.Uint8
+}
+
 // Parse the struct field tag and pull out the JSON name.
 // In general, this is only used when walking structs with JSON only,
 // like ReturnErr/ReturnOK values, or Params values when it is meant for the request body.
@@ -133,6 +280,51 @@ func jsonName(f reflect.StructField) string {
 	return ""
 }
 
+// Parse the struct field tag and pull out the form name,
+// for fields sent in form-encoded (application/x-www-form-urlencoded or multipart/form-data) request bodies.
+func formName(f reflect.StructField) string {
+	formTag := f.Tag.Get("form")
+	if formTag == "-" {
+		return ""
+	}
+	return strings.Split(formTag, ",")[0]
+}
+
+// Return true if any of the fields of struct f have a form name,
+// so its request body should be form-encoded rather than JSON.
+func hasFormFields(f Field) bool {
+	if f.Kind != reflect.Struct {
+		return false
+	}
+	for _, field := range enumerateStructFields(f) {
+		if formName(field.StructField) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the content type for a request body with the form fields of struct f.
+// Use multipart/form-data if any field is a file upload, application/x-www-form-urlencoded otherwise.
+func formContentType(f Field) string {
+	for _, field := range enumerateStructFields(f) {
+		if formName(field.StructField) != "" && isFileField(field) {
+			return ContentTypeMultipartForm
+		}
+	}
+	return ContentTypeFormURLEncoded
+}
+
+var fileHeaderType = reflect.TypeOf(multipart.FileHeader{})
+
+// Return true if f is a multipart.FileHeader (or pointer or slice of them).
+func isFileField(f Field) bool {
+	if f.Kind == reflect.Slice {
+		f = ZeroSliceValueField(f.Type)
+	}
+	return f.Type == fileHeaderType
+}
+
 type docBuilder struct {
 	base *baseBuilder
 }
@@ -142,7 +334,7 @@ func (b *docBuilder) writeLn(indent int, format string, i ...interface{}) {
 }
 
 func (b *docBuilder) writeInfo() {
-	b.writeLn(0, "openapi: 3.0.0")
+	b.writeLn(0, "openapi: %s", b.base.swagger.OpenAPIVersion)
 	b.writeLn(0, "info:")
 	sw := b.base.swagger
 	b.writeLn(1, "title: %s", sw.title)
@@ -160,6 +352,7 @@ func (b *docBuilder) writeInfo() {
 		b.base.writeNotEmpty(2, "url: %s", sw.licenseURL)
 	}
 	b.writeLn(1, "version: %s", sw.version)
+	b.base.writeExtensions(1, sw.InfoExtensions)
 }
 
 func (b *docBuilder) writeTags() {
@@ -168,8 +361,10 @@ func (b *docBuilder) writeTags() {
 	}
 	b.writeLn(0, "tags:")
 	for _, t := range b.base.swagger.tags {
-		b.writeLn(1, "- name: %s", t.name)
-		b.writeLn(1, "  description: %s", t.desc)
+		b.writeLn(1, "- name: %s", t.Name)
+		b.writeLn(1, "  description: %s", t.Description)
+		b.base.writeExternalDocs(2, t.ExternalDocs)
+		b.base.writeExtensions(2, t.Extensions)
 	}
 }
 
@@ -179,11 +374,20 @@ func (b *docBuilder) writeServers() {
 	}
 	b.writeLn(0, "servers:")
 	for _, srv := range b.base.swagger.servers {
-		b.writeLn(1, "- url: %s", srv.url)
-		b.writeLn(1, "  description: %s", srv.desc)
+		b.writeLn(1, "- url: %s", srv.URL)
+		b.writeLn(1, "  description: %s", srv.Description)
+		b.base.writeExtensions(2, srv.Extensions)
 	}
 }
 
+func (b *docBuilder) writeExternalDocs() {
+	b.base.writeExternalDocs(0, b.base.swagger.externalDocs)
+}
+
+func (b *docBuilder) writeExtensions() {
+	b.base.writeExtensions(0, b.base.swagger.Extensions)
+}
+
 type pathBuilder struct {
 	base *baseBuilder
 }
@@ -195,14 +399,16 @@ func (b *pathBuilder) writeLn(indent int, format string, i ...interface{}) {
 func (b *pathBuilder) writePaths() {
 	b.writeLn(0, "paths:")
 
-	contentType := b.base.swagger.DefaultContentType
-
 	lastPath := Path("")
 	lastMethod := Method("")
-	for _, op := range b.sortedOperations() {
+	var pathParams []param
+	ops := b.sortedOperations()
+	for _, op := range ops {
 		// Only write the path and method when they change from operation to operation.
 		if lastPath != op.Path {
 			b.writeLn(1, "%s:", op.Path)
+			pathParams = b.base.swagger.pathParams(op.Path, ops)
+			b.writeParams(2, pathParams, nil)
 			b.writeLn(2, "%s:", op.Method)
 			lastPath = op.Path
 			lastMethod = op.Method
@@ -211,92 +417,310 @@ func (b *pathBuilder) writePaths() {
 			lastMethod = op.Method
 		}
 
-		if len(op.Tags) > 0 {
-			if len(op.Tags) > 0 {
-				b.writeLn(3, `tags: ["%s"]`, strings.Join(op.Tags, `", "`))
+		b.writeOperation(3, op, b.base.swagger.operationID(op), pathParams, b.base.swagger.responsesFor(op))
+	}
+}
+
+// Write the fields of op at indent, which is one level under its method.
+// Parameters in pathParams are not written, since they are written for the path.
+// id is only written if it is not empty.
+func (b *pathBuilder) writeOperation(indent int, op internalOperation, id OperationID, pathParams []param, responses Responses) {
+	if len(op.Tags) > 0 {
+		b.writeLn(indent, `tags: ["%s"]`, strings.Join(op.Tags, `", "`))
+	}
+
+	b.base.writeNotEmpty(indent, "operationId: %s", string(id))
+	b.base.writeNotEmpty(indent, "summary: %s", op.Summary)
+	b.base.writeNotEmpty(indent, "description: %s", op.Description)
+	b.base.writeExternalDocs(indent, op.Original.ExternalDocs)
+	if op.Original.Deprecated {
+		b.writeLn(indent, "deprecated: true")
+	}
+
+	b.writeParams(indent, paramsOf(op.Params), pathParams)
+	if op.useRequestBody() {
+		b.writeRequestBody(indent, op)
+	}
+	b.writeLn(indent, "responses:")
+	for _, resp := range responses {
+		b.writeLn(indent+1, "'%s':", resp.Code)
+		if resp.Ref != "" {
+			b.writeLn(indent+2, "$ref: '%s'", b.base.swagger.responseRefLink(resp.Ref))
+			continue
+		}
+		b.writeResponse(indent+2, resp)
+	}
+	if len(op.Original.Callbacks) > 0 {
+		b.writeCallbacks(indent, op.Original.Callbacks)
+	}
+	if op.Original.Security != nil {
+		b.base.writeSecurityRequirements(indent, op.Original.Security)
+	}
+	b.base.writeExtensions(indent, op.Original.Extensions)
+}
+
+// Write the callbacks map at indent.
+// Callbacks with the same name are grouped together, under each of their expressions.
+// See https://swagger.io/specification/#callbackObject
+func (b *pathBuilder) writeCallbacks(indent int, callbacks []Callback) {
+	b.writeLn(indent, "callbacks:")
+	written := make(map[string]bool, len(callbacks))
+	for _, cb := range callbacks {
+		if written[cb.Name] {
+			continue
+		}
+		written[cb.Name] = true
+		b.writeLn(indent+1, "%s:", cb.Name)
+		for _, other := range callbacks {
+			if other.Name != cb.Name {
+				continue
 			}
+			op := other.Operation.toInternalOperation()
+			b.writeLn(indent+2, "'%s':", other.Expression)
+			b.writeLn(indent+3, "%s:", op.Method)
+			b.writeOperation(indent+4, op, OperationID(op.Original.OperationID), nil, op.Responses)
 		}
+	}
+}
 
-		b.writeLn(3, "operationId: %s", op.OperationID)
-		b.base.writeNotEmpty(3, "summary: %s", op.Summary)
-		b.base.writeNotEmpty(3, "description: %s", op.Description)
+// Write the top-level webhooks map, if there are any webhooks.
+// Panics if the document is not for OpenAPI 3.1, since webhooks were added in 3.1.
+// See https://spec.openapis.org/oas/v3.1.0#oasWebhooks
+func (b *pathBuilder) writeWebhooks() {
+	webhooks := b.base.swagger.webhooks
+	if len(webhooks) == 0 {
+		return
+	}
+	if !b.base.swagger.isOpenAPI31() {
+		panic(fmt.Sprintf("webhook %q requires OpenAPIVersion 3.1, use Operation#AddCallback for OpenAPI 3.0", webhooks[0].name))
+	}
+	b.writeLn(0, "webhooks:")
+	for _, wh := range webhooks {
+		b.writeLn(1, "%s:", wh.name)
+		b.writeLn(2, "%s:", wh.op.Method)
+		b.writeOperation(3, wh.op, OperationID(wh.op.Original.OperationID), nil, wh.op.Responses)
+	}
+}
 
-		if !op.Params.Nil() {
-			b.writeParams(3, op.Params)
-		}
-		if op.useRequestBody() {
-			b.writeLn(3, "requestBody:")
-			b.writeLn(4, "required: true")
-			b.writeLn(4, "content:")
-			b.writeLn(5, "%s:", contentType)
-			b.writeLn(6, "schema:")
-			b.base.writeStructSchema(7, op.Params, func(f Field) bool {
-				// We *always* want to recurse/expand request body struct fields that are structs/slices,
-				// unless they are being terminated into a data type.
-				return !b.base.swagger.isMappedToDataType(f)
-			})
-		}
-		b.writeLn(3, "responses:")
-		for _, resp := range op.Responses {
-			b.writeLn(4, "'%s':", resp.Code)
-			b.writeLn(5, "description: %s", resp.Description)
-			if !resp.Field.Nil() {
-				b.writeLn(5, "content:")
-				switch resp.Field.Kind {
-				case reflect.String:
-					b.writeLn(6, "text/plain:")
-				default:
-					b.writeLn(6, "%s:", contentType)
-				}
-				b.writeLn(7, "schema:")
-				b.base.writeRefSchema(8, resp.Field)
+// Write the description, headers, and content of resp.
+func (b *pathBuilder) writeResponse(indent int, resp Response) {
+	b.writeLn(indent, "description: %s", resp.Description)
+	if !resp.Headers.Nil() {
+		b.writeResponseHeaders(indent, resp.Headers)
+	}
+	if mediaTypes := b.responseMediaTypes(resp); len(mediaTypes) > 0 {
+		b.writeLn(indent, "content:")
+		for _, mt := range mediaTypes {
+			if mt.Field.Nil() {
+				b.writeLn(indent+1, "%s: {}", mt.ContentType)
+				continue
 			}
+			b.writeLn(indent+1, "%s:", mt.ContentType)
+			b.writeLn(indent+2, "schema:")
+			b.base.writeRefSchema(indent+3, mt.Field)
+			b.base.writeExamples(indent+2, mt.Field, resp.Examples)
 		}
 	}
+	if links := b.base.swagger.linksOf(resp); len(links) > 0 {
+		b.writeLinks(indent, links)
+	}
+	b.base.writeExtensions(indent, resp.Extensions)
 }
 
-func (b *pathBuilder) writeParams(indent int, f Field) {
-	if f.Kind != reflect.Struct {
-		b.base.writeLn(indent, "requestBody:")
-		b.base.writeLn(indent+1, "content:")
-		b.base.writeLn(indent+2, "*/*:")
-		b.base.writeLn(indent+3, "schema:")
-		if f.Kind == reflect.Map {
-			b.base.writeLn(indent+4, "type: object")
-		} else if f.Kind == reflect.Slice {
-			b.base.writeLn(indent+4, "type: array")
+// Write the links of a response, which Validate checked, at indent.
+// See https://swagger.io/specification/#linkObject
+func (b *pathBuilder) writeLinks(indent int, links []Link) {
+	b.writeLn(indent, "links:")
+	for _, link := range links {
+		target, _ := b.base.swagger.findLinkTarget(link)
+		b.writeLn(indent+1, "%s:", link.Name)
+		b.writeLn(indent+2, "operationId: %s", b.base.swagger.operationID(target))
+		if len(link.Parameters) > 0 {
+			b.writeLn(indent+2, "parameters:")
+			for _, name := range link.sortedParameters() {
+				b.writeLn(indent+3, "%s: %s", name, exampleJSON(link.Parameters[name]))
+			}
 		}
-		return
+		b.base.writeNotEmpty(indent+2, "description: %s", link.Description)
 	}
-	writeParams := b.base.writeOnce(indent, "parameters:")
+}
+
+// Write the headers map for the "header" tagged fields of struct f.
+// Headers are only required if their struct tags say so (see paramRequired).
+// See https://swagger.io/specification/#headerObject
+func (b *pathBuilder) writeResponseHeaders(indent int, f Field) {
+	writeHeaders := b.base.writeOnce(indent, "headers:")
 	for _, field := range enumerateStructFields(f) {
 		tag := field.StructField.Tag
-		var name, in string
-
-		if path := tag.Get("path"); path != "" {
-			name = path
-			in = "path"
-		} else if query := tag.Get("query"); query != "" {
-			name = query
-			in = "query"
-		} else if header := tag.Get("header"); header != "" {
-			name = header
-			in = "header"
-		} else {
+		name := tag.Get("header")
+		if name == "" {
 			continue
 		}
-		writeParams()
-		b.writeLn(indent+1, "- name: %s", name)
-		b.writeLn(indent+1, "  in: %s", in)
-		if in == "path" {
-			b.writeLn(indent+1, "  required: true")
+		writeHeaders()
+		b.writeLn(indent+1, "%s:", name)
+		b.base.writeNotEmpty(indent+2, "description: %s", tag.Get("description"))
+		if paramRequired(field, "header", nil) {
+			b.writeLn(indent+2, "required: true")
 		}
-		b.base.writeNotEmpty(indent+1, "  description: %s", tag.Get("description"))
-		b.writeLn(indent+1, "  schema:")
+		b.writeLn(indent+2, "schema:")
 		b.base.writeRefSchema(indent+3, field)
 	}
 }
 
+// Return the media types for resp: its own MediaTypes if set (using resp's shape for those without one),
+// or a MediaType with resp's shape for each of its content types.
+// The content types are its own ContentTypes if set,
+// otherwise text/plain for strings, and the document's DefaultContentType for everything else.
+// Responses with no shape and no MediaTypes have no content.
+func (b *pathBuilder) responseMediaTypes(resp Response) MediaTypes {
+	if len(resp.MediaTypes) > 0 {
+		result := make(MediaTypes, 0, len(resp.MediaTypes))
+		for _, mt := range resp.MediaTypes {
+			if mt.Field.Nil() {
+				mt.Field = resp.Field
+			}
+			result = append(result, mt)
+		}
+		return result
+	}
+	if resp.Field.Nil() {
+		return nil
+	}
+	contentTypes := resp.ContentTypes
+	if len(contentTypes) == 0 && resp.Field.Kind == reflect.String {
+		contentTypes = []string{"text/plain"}
+	} else if len(contentTypes) == 0 {
+		contentTypes = []string{b.base.swagger.DefaultContentType}
+	}
+	result := make(MediaTypes, 0, len(contentTypes))
+	for _, ct := range contentTypes {
+		result = append(result, MediaType{ContentType: ct, Field: resp.Field})
+	}
+	return result
+}
+
+func (b *pathBuilder) writeRequestBody(indent int, op internalOperation) {
+	sw := b.base.swagger
+	b.writeLn(indent, "requestBody:")
+	if b.base.refRequestBody(op) {
+		b.writeLn(indent+1, "$ref: '%s'", requestBodyRefLink(op.bodyField()))
+		return
+	}
+	b.writeLn(indent+1, "required: true")
+	b.writeLn(indent+1, "content:")
+	for _, mt := range sw.requestMediaTypes(op) {
+		b.writeLn(indent+2, "%s:", mt.ContentType)
+		b.writeLn(indent+3, "schema:")
+		b.writeRequestBodySchema(indent+4, mt)
+		if !hasFormFields(mt.Field) && mt.ContentType != ContentTypeJSONPatch {
+			b.base.writeExamples(indent+3, mt.Field, op.Original.RequestExamples)
+		}
+	}
+}
+
+// Write the schema for the request body for the media type mt.
+// The encoding object for form bodies is written after the schema, one indent level lower.
+func (b *pathBuilder) writeRequestBodySchema(indent int, mt MediaType) {
+	f := mt.Field
+	if mt.ContentType == ContentTypeJSONPatch {
+		b.writeJSONPatchSchema(indent)
+		return
+	}
+	if b.base.swagger.refBodyStruct(f) {
+		b.writeLn(indent, "$ref: '%s'", schemaRefLink(f))
+		return
+	}
+	if hasFormFields(f) {
+		b.base.writeNamedStructSchema(indent, f, formName, func(f Field) bool {
+			return !b.base.swagger.isMappedToDataType(f)
+		})
+		b.writeFormEncoding(indent-1, f)
+		return
+	}
+	// We *always* want to recurse/expand request body struct fields that are structs/slices,
+	// unless they are being terminated into a data type.
+	b.base.writeBodySchema(indent, f)
+}
+
+// Write the schema for a JSON Patch document, which is the same regardless of the resource being patched.
+// See https://tools.ietf.org/html/rfc6902
+func (b *pathBuilder) writeJSONPatchSchema(indent int) {
+	b.writeLn(indent, "type: array")
+	b.writeLn(indent, "items:")
+	b.writeLn(indent+1, "type: object")
+	b.writeLn(indent+1, "required: [op, path]")
+	b.writeLn(indent+1, "properties:")
+	b.writeLn(indent+2, "op:")
+	b.writeLn(indent+3, "type: string")
+	b.writeLn(indent+3, "enum: [add, remove, replace, move, copy, test]")
+	b.writeLn(indent+2, "path:")
+	b.writeLn(indent+3, "type: string")
+	b.writeLn(indent+2, "value: {}")
+	b.writeLn(indent+2, "from:")
+	b.writeLn(indent+3, "type: string")
+}
+
+// Write the encoding object for form fields that specify the content type
+// of their part through the "contentType" struct tag, like `form:"avatar" contentType:"image/png"`.
+// See https://swagger.io/specification/#encodingObject
+func (b *pathBuilder) writeFormEncoding(indent int, f Field) {
+	writeEncoding := b.base.writeOnce(indent, "encoding:")
+	for _, field := range enumerateStructFields(f) {
+		name := formName(field.StructField)
+		contentType := field.StructField.Tag.Get("contentType")
+		if name == "" || contentType == "" {
+			continue
+		}
+		writeEncoding()
+		b.writeLn(indent+1, "%s:", name)
+		b.writeLn(indent+2, "contentType: %s", contentType)
+	}
+}
+
+// Write the parameters in params, except those that are the same as one in omit
+// (like operation parameters which are already declared for the path).
+func (b *pathBuilder) writeParams(indent int, params []param, omit []param) {
+	writeParams := b.base.writeOnce(indent, "parameters:")
+	for _, p := range params {
+		if containsParam(omit, p) {
+			continue
+		}
+		writeParams()
+		b.writeParam(indent+1, p)
+	}
+}
+
+func (b *pathBuilder) writeParam(indent int, p param) {
+	field := p.field
+	tag := field.StructField.Tag
+	b.writeLn(indent, "- name: %s", p.name)
+	b.writeLn(indent, "  in: %s", p.in)
+	if paramRequired(field, p.in, b.base.swagger.ParamRequirer) {
+		b.writeLn(indent, "  required: true")
+	}
+	b.base.writeNotEmpty(indent, "  description: %s", tag.Get("description"))
+	isObject := field.Kind == reflect.Struct && !b.base.swagger.isMappedToDataType(field)
+	style, explode := paramSerialization(field, p.in, isObject)
+	b.base.writeNotEmpty(indent, "  style: %s", style)
+	b.base.writeNotEmpty(indent, "  explode: %s", explode)
+	b.writeLn(indent, "  schema:")
+	if isObject {
+		// Object parameters, like deepObject filters, are written inline,
+		// since their fields are only ever described by the parameter.
+		b.base.writeStructSchema(indent+2, field, func(f Field) bool {
+			return !b.base.swagger.isMappedToDataType(f)
+		})
+	} else {
+		b.base.writeRefSchema(indent+2, field)
+	}
+	if example := tag.Get("example"); example != "" {
+		b.writeLn(indent, "  example: %s", example)
+	} else {
+		b.base.writeExamples(indent+1, field, nil)
+	}
+	b.base.writeTagExtensions(indent+1, field)
+}
+
 // Return a slice of operations such that they are sorted by path and then by method.
 // So ops of {/xyz POST, /abc GET, /xyz GET, /abc POST}
 // will sort to {/abc GET, /abc POST, /xyz GET, /xyz POST}.
@@ -338,6 +762,17 @@ func (b *componentsBuilder) writeComponents() {
 		b.writeSchemas(sortedSchemas)
 	}
 
+	if len(b.base.swagger.namedResponses) > 0 {
+		writeComponents()
+		b.writeResponses()
+	}
+
+	sortedRequestBodies := b.sortedRequestBodies()
+	if len(sortedRequestBodies) > 0 {
+		writeComponents()
+		b.writeRequestBodies(sortedRequestBodies)
+	}
+
 	if len(b.base.swagger.securities) > 0 {
 		writeComponents()
 		b.writeSecuritySchemas()
@@ -350,9 +785,57 @@ func (b *componentsBuilder) writeSchemas(sortedSchemas Fields) {
 	for _, tv := range sortedSchemas {
 		b.base.writeLn(2, "%s:", tv.Type.Name())
 		b.base.writeStructSchema(3, tv, b.shouldRecurseStructField)
+		if example, ok := b.base.swagger.schemaExamples[tv.Type]; ok {
+			b.base.writeLn(3, "example: %s", exampleJSON(example))
+		}
+		b.base.writeExtensions(3, b.base.swagger.schemaExtensions[tv.Type])
+	}
+}
+
+func (b *componentsBuilder) writeResponses() {
+	pb := pathBuilder{b.base}
+	b.base.writeLn(1, "responses:")
+	for _, nr := range b.base.swagger.sortedNamedResponses() {
+		b.base.writeLn(2, "%s:", nr.name)
+		pb.writeResponse(3, nr.resp)
+	}
+}
+
+func (b *componentsBuilder) writeRequestBodies(sortedRequestBodies []internalOperation) {
+	b.base.writeLn(1, "requestBodies:")
+	for _, op := range sortedRequestBodies {
+		b.base.writeLn(2, "%s:", op.bodyField().Type.Name())
+		b.base.writeLn(3, "required: true")
+		b.base.writeLn(3, "content:")
+		b.base.writeLn(4, "%s:", b.base.swagger.requestContentType(op))
+		b.base.writeLn(5, "schema:")
+		b.base.writeLn(6, "$ref: '%s'", schemaRefLink(op.bodyField()))
 	}
 }
 
+// Return the operations with request bodies written into components/requestBodies,
+// with one operation for each Params type (the first by path and method
+// with the component's content type, see refRequestBody), sorted by type name.
+func (b *componentsBuilder) sortedRequestBodies() []internalOperation {
+	sw := b.base.swagger
+	result := make([]internalOperation, 0)
+	if sw.RequestBodyRefs != RequestBodyComponentRefs {
+		return result
+	}
+	seen := make(map[reflect.Type]bool)
+	pb := pathBuilder{b.base}
+	for _, op := range append(pb.sortedOperations(), sw.outboundOperations()...) {
+		if op.useRequestBody() && b.base.refRequestBody(op) && !seen[op.bodyField().Type] {
+			seen[op.bodyField().Type] = true
+			result = append(result, op)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].bodyField().Type.Name() < result[j].bodyField().Type.Name()
+	})
+	return result
+}
+
 // A type will end up in the schema if it has a name and is exported.
 // Inline types (no name) amd embedded structs (Anonymous) should be traversed.
 // Assume lowercase named isn't meant for the swagger doc.
@@ -374,9 +857,23 @@ func (b *componentsBuilder) sortedFieldsForSchema() Fields {
 		allFields = append(allFields, f)
 	}
 	for _, op := range b.base.swagger.operations {
+		for _, resp := range b.base.swagger.responsesFor(op) {
+			b.visitResponseStructs(resp, visitor)
+		}
+		if op.useRequestBody() {
+			b.visitRequestBodyStructs(op, visitor)
+		}
+	}
+	for _, op := range b.base.swagger.outboundOperations() {
 		for _, resp := range op.Responses {
-			b.visitStructs(resp.Field, visitor)
+			b.visitResponseStructs(resp, visitor)
 		}
+		if op.useRequestBody() {
+			b.visitRequestBodyStructs(op, visitor)
+		}
+	}
+	for _, nr := range b.base.swagger.namedResponses {
+		b.visitResponseStructs(nr.resp, visitor)
 	}
 	relevantSortedFields := allFields.
 		Compact().
@@ -387,6 +884,61 @@ func (b *componentsBuilder) sortedFieldsForSchema() Fields {
 	return relevantSortedFields
 }
 
+func (b *componentsBuilder) visitResponseStructs(resp Response, visitor func(Field)) {
+	b.visitStructs(resp.Field, visitor)
+	for _, mt := range resp.MediaTypes {
+		b.visitStructs(mt.Field, visitor)
+	}
+}
+
+// Visit the structs in the request body of op that are written as components:
+// the body itself when request body refs are used,
+// or the items of slice and map bodies, at any depth (see writeBodySchema).
+func (b *componentsBuilder) visitRequestBodyStructs(op internalOperation, visitor func(Field)) {
+	for _, mt := range b.base.swagger.requestMediaTypes(op) {
+		f := mt.Field
+		if mt.ContentType == ContentTypeJSONPatch {
+			continue
+		}
+		if b.base.swagger.refBodyStruct(f) {
+			b.visitStructs(f, visitor)
+		} else {
+			b.visitBodyItemStructs(f, visitor)
+		}
+	}
+}
+
+// Visit the structs referenced with $ref by the items of the slice or map body f,
+// recursing through nested slices and maps like writeBodySchema and writeBodyItemSchema do.
+func (b *componentsBuilder) visitBodyItemStructs(f Field, visitor func(Field)) {
+	if f.Nil() || isBytesField(f) {
+		return
+	}
+	var swaggerType string
+	switch f.Kind {
+	case reflect.Slice:
+		swaggerType = "array"
+	case reflect.Map:
+		swaggerType = "object"
+	default:
+		return
+	}
+	// Collections mapped to a data type of another type, like a string, have no items (see writeCollectionType).
+	if dtd, found := b.base.swagger.dataTypeDefFor(f); found {
+		of := ObjectFields{}
+		dtd.DataTyper(f, of)
+		if of["type"] != swaggerType {
+			return
+		}
+	}
+	item := zeroElemField(f.Type)
+	if b.base.isRefStruct(item) {
+		b.visitStructs(item, visitor)
+	} else {
+		b.visitBodyItemStructs(item, visitor)
+	}
+}
+
 func (b *componentsBuilder) visitStructs(f Field, visitor func(Field)) {
 	if f.Kind == reflect.Slice {
 		f = ZeroSliceValueField(f.Type)
@@ -408,16 +960,37 @@ func (b *componentsBuilder) visitStructs(f Field, visitor func(Field)) {
 func (b *componentsBuilder) writeSecuritySchemas() {
 	b.base.writeLn(1, "securitySchemes:")
 	for _, sec := range b.base.swagger.securities {
+		if sec.scheme.Type == "mutualTLS" && !b.base.swagger.isOpenAPI31() {
+			panic(fmt.Sprintf("security scheme %q of type mutualTLS requires OpenAPIVersion 3.1", sec.ID()))
+		}
 		b.base.writeLn(2, "%s:", sec.ID())
 		for _, tuple := range sec.Fields().Sorted() {
 			b.base.writeLn(3, "%s: %s", tuple[0], tuple[1])
 		}
+		if len(sec.scheme.Flows) > 0 {
+			b.writeOAuthFlows(3, sec.scheme.Flows)
+		}
 	}
 }
 
-func (b *componentsBuilder) writeSecurityScopes() {
-	b.base.writeLn(0, "security:")
-	for _, sec := range b.base.swagger.securities {
-		b.base.writeLn(1, "- %s: []", sec.ID())
+func (b *componentsBuilder) writeOAuthFlows(indent int, flows []OAuthFlow) {
+	b.base.writeLn(indent, "flows:")
+	for _, flow := range flows {
+		b.base.writeLn(indent+1, "%s:", flow.Type)
+		b.base.writeNotEmpty(indent+2, "authorizationUrl: %s", flow.AuthorizationURL)
+		b.base.writeNotEmpty(indent+2, "tokenUrl: %s", flow.TokenURL)
+		b.base.writeNotEmpty(indent+2, "refreshUrl: %s", flow.RefreshURL)
+		if len(flow.Scopes) == 0 {
+			b.base.writeLn(indent+2, "scopes: {}")
+			continue
+		}
+		b.base.writeLn(indent+2, "scopes:")
+		for _, name := range flow.sortedScopes() {
+			b.base.writeLn(indent+3, "%s: %s", name, flow.Scopes[name])
+		}
 	}
 }
+
+func (b *componentsBuilder) writeSecurityScopes() {
+	b.base.writeSecurityRequirements(0, b.base.swagger.globalSecurity())
+}
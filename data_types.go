@@ -1,6 +1,7 @@
 package sashay
 
 import (
+	"mime/multipart"
 	"sort"
 	"time"
 )
@@ -99,6 +100,8 @@ func BuiltinDataTyperFor(value interface{}, chained ...DataTyper) DataTyper {
 		dt = SimpleDataTyper("number", "float")
 	case time.Time, *time.Time:
 		dt = SimpleDataTyper("string", "date-time")
+	case multipart.FileHeader, *multipart.FileHeader:
+		dt = SimpleDataTyper("string", "binary")
 	case map[string]interface{}:
 		dt = SimpleDataTyper("object", "")
 	case []interface{}, []map[string]interface{}:
@@ -102,7 +102,7 @@ For more information on any of these, you can refer to the OpenAPI documentation
 as it maps cleanly.
 
 This code uses "apiKey" security, via AddAPIKeySecurity. The sashay.Sashay object also has
-AddBasicAuthSecurity and AddJWTSecurity methods available.
+AddBasicAuthSecurity, AddJWTSecurity, AddOAuth2Security, and AddOpenIDConnectSecurity methods available.
 
 # Tutorial Step 2- Define Operations
 
@@ -263,7 +263,8 @@ Let's look at the Parameters field in the following sashay.Operation definition:
 		nil,
 	)
 
-The struct tags of "path", "header", and "query" define the name of the parameter in the path/header/query.
+The struct tags of "path", "header", "query", and "cookie" define the name of the parameter
+in the path/header/query/cookie.
 Using the "json" tag indicates the parameter is included in the request body.
 This Operation generates the following YAML:
 
@@ -340,10 +341,17 @@ The actual getUsersHandler code uses the same struct to describe itself as it do
 The same is true for response types- the schema is built from the real objects, with the json struct tags,
 not separate documentation.
 
-Note that Sashay never uses $ref for parameters (resources in POST/PUT request bodies).
+By default, Sashay does not use $ref for parameters (resources in POST/PUT request bodies).
 Even if the same type is used for a request and a response,
 it'll be expanded in the requestBody section and a $ref in the response section.
-This may change in the future.
+If your Params are exported named structs shared between operations,
+you can set Sashay.RequestBodyRefs to RequestBodySchemaRefs,
+so the request body schema is written once into components/schemas and referenced with $ref,
+or to RequestBodyComponentRefs, so the entire request body is written into components/requestBodies.
+A requestBodies component has the content type of the first Operation added with its type;
+Operations using the type with another content type have their request body written inline.
+Path, query, header, and cookie fields are still written as parameters,
+and are not part of the schema.
 
 # Sashay Detail- Request Bodies
 
@@ -386,6 +394,87 @@ You can see the requestBody YAML it generates:
 						last:
 						  type: string
 
+POST, PUT, and PATCH operations get a requestBody if they have Params.
+Other methods, like GET and DELETE, do not.
+Use Operation.WithRequestBody or Operation.WithoutRequestBody to override this,
+like for a DELETE endpoint that takes a body.
+
+The request body uses the Sashay's DefaultContentType,
+unless the Operation has a RequestContentType.
+For example, a JSON Merge Patch endpoint would use:
+
+	sashay.NewOperation(
+		"PATCH",
+		"/users/:id",
+		"Update a user.",
+		userPatchParams{},
+		User{},
+		ErrorModel{},
+	).WithRequestContentType(sashay.ContentTypeMergePatch)
+
+When the RequestContentType is ContentTypeJSONPatch,
+the body is documented as a JSON Patch document (an array of add/remove/replace/etc operations),
+rather than from the json fields of Params.
+
+Params can describe both parameters and the request body,
+but sometimes the body is not a struct, or is a type shared with other code.
+Use Operation.Body to declare the request body separately from Params.
+Body can be any Go value: a struct, slice, map, string (text/plain), or []byte (application/octet-stream).
+When Body is set, it is always used for the request body, regardless of method,
+and Params is only used for parameters:
+
+	sashay.Operation{
+		Method:  "POST",
+		Path:    "/users/:id/addresses",
+		Summary: "Add addresses to a user.",
+		Params: struct {
+			ID int `path:"id"`
+		}{},
+		Body: []Address{},
+	}
+
+Slice items and map values which are exported structs, like Address, are written as a $ref.
+Params which are not structs, like []Address{} or map[string]int{}, are treated the same as Body,
+since they cannot describe any parameters.
+
+# Sashay Detail- Forms and File Uploads
+
+Fields with a "form" struct tag are sent in a form-encoded request body rather than JSON.
+If any form field is a file (*multipart.FileHeader or a slice of them),
+the content type is multipart/form-data, otherwise it is application/x-www-form-urlencoded.
+Use the "contentType" struct tag to document the content type of a file part:
+
+	sashay.NewOperation(
+		"POST",
+		"/users/:id/avatar",
+		"Upload an avatar.",
+		struct {
+			ID      int                   `path:"id"`
+			Caption string                `form:"caption"`
+			Avatar  *multipart.FileHeader `form:"avatar" contentType:"image/png, image/jpeg"`
+		}{},
+		nil,
+		nil,
+	)
+
+This generates the following requestBody:
+
+	requestBody:
+	  required: true
+	  content:
+	    multipart/form-data:
+	      schema:
+	        type: object
+	        properties:
+	          caption:
+	            type: string
+	          avatar:
+	            type: string
+	            format: binary
+	      encoding:
+	        avatar:
+	          contentType: image/png, image/jpeg
+
 # Sashay Detail- Representing Custom Types
 
 Note that out of the box, Sashay will treat simple custom types (like `type MyString string`)
@@ -625,6 +714,45 @@ In this way, the default codes can be overwritten, and multiple responses can be
 				  schema:
 					$ref: '#/components/schemas/TeapotError'
 
+Error responses usually come from shared code, like middleware or error handlers,
+so Sashay can document them for all operations.
+Use MapErrorCode to document a ReturnErr shape under a specific status code rather than 'default',
+and AddErrorResponse to add an error response to every operation:
+
+	sw.MapErrorCode(ValidationError{}, 422, "The parameters were invalid.")
+	sw.AddErrorResponse(401, "The request was not authenticated.", ErrorModel{})
+	sw.AddErrorResponse(429, "Too many requests.", ErrorModel{})
+
+Operations can add their own error responses with Operation.AddErrorResponses,
+and remove document-wide error responses with Operation.WithoutErrorCodes:
+
+	sashay.NewOperation("POST", "/login", "Log in.", loginParams{}, Session{}, ValidationError{}).
+		AddErrorResponses(sashay.NewResponse(403, "The account is locked.", ErrorModel{})).
+		WithoutErrorCodes(401)
+
+Responses are written in the order they are declared, followed by document-wide error responses,
+with 'default' last.
+If a mapped status code is the same as one of the operation's own responses, the operation's response is used,
+and the error is written as the 'default' response.
+Sashay panics if an operation declares more than one response for a status code.
+
+Responses shared by many operations can also be defined once, written into components/responses,
+and referenced with $ref. Use DefineResponse to define a named response,
+and NewResponseRef to reference it from an Operation,
+or UseResponse to reference it from all Operations (or all Operations with any of the given tags):
+
+	sw.DefineResponse("Unauthorized", sashay.NewResponse(401, "Not authenticated.", ErrorModel{}))
+	sw.DefineResponse("Forbidden", sashay.NewResponse(403, "Not allowed.", ErrorModel{}))
+	sw.UseResponse(401, "Unauthorized")
+	sw.UseResponse(403, "Forbidden", "admin")
+
+Responses can also document headers, like Location or Retry-After.
+Use a struct with "header" tags, like you would for Params:
+
+	sashay.NewResponse(201, "The created user.", User{}).WithHeaders(struct {
+		Location string `header:"Location" description:"URL of the new user." required:"true"`
+	}{})
+
 Finally, there are a couple special cases for responses:
 
 - If a response is a string type, rather than a struct,
@@ -632,6 +760,42 @@ it is assumed to be of content type text/plain.
 
 - If a response is an empty struct (`struct{}{}`), use application/json with no schema.
 
+Content types can also vary per-endpoint and per-response.
+Operation.ResponseContentTypes and Operation.ErrorContentTypes set the content types
+of responses built from ReturnOk and ReturnErr, respectively,
+and Response.ContentTypes sets the content types of a single response.
+Each content type uses the same schema:
+
+	sashay.NewOperation(
+		"GET",
+		"/users/export",
+		"Export users.",
+		nil,
+		[]User{},
+		ErrorModel{},
+	).
+		WithResponseContentTypes("application/json", "text/csv").
+		WithErrorContentTypes("application/problem+json")
+
+When each content type has a different schema, use MediaTypes instead.
+Response.MediaTypes and Operation.RequestMediaTypes are lists of content types,
+each with its own shape. A nil shape uses the shape of the response or request body:
+
+	sashay.NewOperation(
+		"PUT",
+		"/users/:id",
+		"Update a user.",
+		userParams{},
+		sashay.NewResponse(200, "The updated user.", User{}).WithMediaTypes(
+			sashay.NewMediaType("application/json", nil),
+			sashay.NewMediaType("application/xml", UserXML{}),
+		),
+		ErrorModel{},
+	).WithRequestMediaTypes(
+		sashay.NewMediaType("application/json", nil),
+		sashay.NewMediaType("application/xml", UserXML{}),
+	)
+
 # Sashay Detail- Pointer Fields
 
 Sashay treats value and pointer fields the same.
@@ -640,13 +804,264 @@ When you register a data type (refer to DefineDataType),
 the same DataTyper is used for pointer fields of that type.
 
 The primary use case for pointer fields in Go is to represent optional fields.
-There's nothing much for Sashay to do with that information,
-because both parameters and object fields are optional/not-required in Swagger by default.
+Both parameters and object fields are optional/not-required in Swagger by default.
 For example, in parameters, "required: false" is the default.
 And for schemas (request bodies, responses), the "nullable: true" attribute
 is quite semantically different than the "optional" meant by a Go pointer field.
 
-In the future, Sashay may support more more extensive specification around required fields,
-but not right now.
+# Sashay Detail- Required Parameters
+
+Path parameters are always required. Query, header, and cookie parameters are optional,
+unless one of the following is true:
+
+- The field has a `required:"true"` struct tag. A `required:"false"` tag always makes the parameter optional.
+
+- The field has a "required" validation in its `validate` struct tag, like `validate:"required,min=1"`.
+
+- The Sashay.ParamRequirer policy returns true for the field.
+
+The default policy, OptionalParams, never requires a parameter.
+If your handlers use pointer fields for optional parameters,
+you can set the NonPointerParamsRequired policy, so all non-pointer parameters are required:
+
+	sw.ParamRequirer = sashay.NonPointerParamsRequired
+	sw.Add(sashay.NewOperation(
+		"GET",
+		"/users",
+		"Get users.",
+		struct {
+			Status  string `query:"status"`
+			Page    *int   `query:"page"`
+			Verbose bool   `header:"X-Verbose" required:"false"`
+		}{},
+		nil,
+		nil,
+	))
+
+This marks the "status" parameter as required, while "page" and "X-Verbose" are optional.
+
+# Sashay Detail- Parameter Serialization
+
+Parameters that are arrays or objects can be sent in several ways,
+like ?ids=1,2,3 or ?ids=1&ids=2&ids=3.
+Use the "style" and "explode" struct tags to document which way your endpoint expects:
+
+	type Filter struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	struct {
+		IDs    []string `query:"ids" style:"form" explode:"false"`
+		Colors []string `query:"colors" style:"pipeDelimited"`
+		Filter Filter   `query:"filter"`
+	}{}
+
+Struct-typed query parameters (which are not mapped to a data type) default to
+"style: deepObject" and "explode: true", like ?filter[name]=spot&filter[age]=5,
+and their schema is written inline using the json names of their fields.
+
+Sashay panics if a style is not valid for the parameter's location.
+See https://swagger.io/docs/specification/serialization/ for more information.
+
+# Sashay Detail- Operation IDs
+
+By default, operationIds are derived from the method and path, like getUsersId for GET /users/:id.
+Set Operation.OperationID (or use WithOperationID) to choose your own,
+or set Sashay.OperationIDer to change how they are derived.
+HandlerOperationID uses the name of the Operation's Handler function:
+
+	sw.OperationIDer = sashay.HandlerOperationID
+	sw.Add(sashay.NewOperation("GET", "/users/:id", "Get a user.", nil, User{}, ErrorModel{}).
+		WithHandler(usersController.GetUser)) // operationId: getUser
+
+Paths which differ only by punctuation, like /users/:id and /users/id, get the same derived operationId.
+WriteYAML returns an error (and BuildYAML panics) if two Operations have the same operationId;
+call Validate to check for this.
+
+# Sashay Detail- Deprecated Operations
+
+Mark an Operation as deprecated with WithDeprecated, or with WithSunset and WithReplacement,
+which also note when it will be removed and what replaces it:
+
+	sw.Add(sashay.NewOperation("GET", "/users", "List users.", nil, []User{}, ErrorModel{}).
+		WithSunset(time.Date(2030, 1, 15, 0, 0, 0, 0, time.UTC)).
+		WithReplacement(listUsersV2))
+
+The Operation is written with "deprecated: true", and a note like
+"This operation is deprecated and will be removed on 2030-01-15. Use GET /v2/users instead."
+is appended to its description.
+Use WithoutDeprecated to create a registry without deprecated Operations.
+
+# Sashay Detail- Vendor Extensions
+
+Vendor extensions (keys starting with "x-") can be set on the root of the document and its info
+(Sashay.Extensions and Sashay.InfoExtensions), on Operations and Responses (WithExtensions),
+on tags and servers (DefineTag and DefineServer), and on schemas (DefineSchemaExtensions).
+Their values are serialized with encoding/json:
+
+	sw.InfoExtensions = sashay.Extensions{"x-logo": map[string]string{"url": "logo.png"}}
+	sw.DefineTag(sashay.Tag{Name: "user", Extensions: sashay.Extensions{"x-displayName": "Users"}})
+	op.WithExtensions(sashay.Extensions{"x-rate-limit": 100})
+
+Parameters and schema properties use "x-" struct tags, which are written as-is, like the "example" tag:
+
+	struct {
+		ID int `path:"id" x-go-name:"UserID"`
+	}{}
+
+They are not written for properties that are a $ref to another schema,
+since OpenAPI 3.0 ignores the siblings of a $ref.
+
+Sashay panics if an Extensions key does not start with "x-".
+
+# Sashay Detail- Response Links
+
+Links describe how values from a response can be used to call other Operations,
+like getting a user with the id returned when creating it.
+Add them to a Response, targeting an Operation (by value with NewLink, or by operationId with NewLinkToID)
+and mapping its parameters to values from the response:
+
+	getUser := sw.Add(sashay.NewOperation("GET", "/users/:id", "Get a user.", GetUserParams{}, User{}, ErrorModel{}))
+	sw.Add(sashay.NewOperation("POST", "/users", "Create a user.", CreateUserParams{},
+		sashay.NewResponse(201, "The created user.", User{}).AddLinks(
+			sashay.NewLink("GetUserByID", getUser, map[string]string{"id": "$response.body#/id"})),
+		ErrorModel{}))
+
+WriteYAML returns an error (and BuildYAML panics) if the target of a link is not added to the Sashay,
+or does not have a parameter the link maps, or if a link's name is empty or uses characters
+other than letters, digits, '.', '-', and '_'.
+Only Operations added with Add can be targeted, not webhooks or callbacks.
+Links to Operations removed with SelectMap (like WithoutDeprecated) are dropped.
+
+# Sashay Detail- Callbacks and Webhooks
+
+Requests the API sends to its clients are described with Operations too:
+their Params or Body is the payload, and ReturnOk and ReturnErr are the responses the API expects.
+Their Method defaults to POST, and their Path is not used.
+
+Callbacks, like notifications sent to a URL the client registers, are added to the Operation they belong to:
+
+	sw.Add(sashay.NewOperation("POST", "/subscriptions", "Subscribe to events.", SubscribeParams{}, nil, ErrorModel{}).
+		AddCallback("onEvent", "{$request.body#/callbackUrl}", sashay.Operation{Body: Event{}}))
+
+Webhooks, which are not tied to an Operation, are added with AddWebhook.
+They are only part of OpenAPI 3.1, so set Sashay.OpenAPIVersion to OpenAPIVersion31 to use them:
+
+	sw.OpenAPIVersion = sashay.OpenAPIVersion31
+	sw.AddWebhook("newPet", sashay.Operation{Body: Pet{}, ReturnOk: sashay.NewResponse(200, "Received.", nil)})
+
+The operationIds of callbacks and webhooks are only written if their OperationID is set.
+
+# Sashay Detail- External Docs
+
+Link to documentation outside of the API reference, like guides and runbooks,
+with SetExternalDocs for the document, Tag.ExternalDocs for tags (see DefineTag),
+and WithExternalDocs for Operations:
+
+	sw.SetExternalDocs("https://docs.example.com", "Guides")
+	op.WithExternalDocs("https://runbooks.example.com/delete-users", "Runbook")
+
+# Sashay Detail- Path-Level Parameters
+
+Parameters like the org in /orgs/:orgId/teams/:teamId are often repeated by every endpoint under a path.
+Use AddPathParams to write them once, in the parameters of every path starting with the prefix:
+
+	sw.AddPathParams("/orgs/:orgId", struct {
+		OrgID string `path:"orgId"`
+	}{})
+
+Operations can still declare the parameter in their Params.
+If it is declared the same way, it is not repeated;
+if it is declared differently (like with a different description), it is written on the operation,
+and overrides the path-level parameter.
+
+Set Sashay.ShareCommonParams to also write parameters declared the same way
+by every Operation on a path into the path's parameters.
+
+# Sashay Detail- Operation Security
+
+Security schemes added to the Sashay, like with AddJWTSecurity, apply to every Operation by default.
+Operations can override this:
+
+	// Public endpoints, like /health or /login, are written with "security: []".
+	sashay.NewOperation("GET", "/health", "Health check.", nil, nil, nil).Public()
+	// Allow either a JWT or an API key.
+	op.RequireSecurity("bearerAuth", "apiKeyAuth")
+	// Require a JWT and an API key together.
+	op.RequireAllSecurity("bearerAuth", "apiKeyAuth")
+
+OAuth2 schemes are added with AddOAuth2Security, along with their flows and the scopes they define,
+and OpenID Connect schemes with AddOpenIDConnectSecurity.
+Operations can require scopes from them with RequireScopes:
+
+	sw.AddOAuth2Security(sashay.OAuthFlow{
+		Type:             sashay.OAuthFlowAuthorizationCode,
+		AuthorizationURL: "https://example.com/oauth/authorize",
+		TokenURL:         "https://example.com/oauth/token",
+		Scopes:           map[string]string{"pets:read": "Read pets.", "pets:write": "Modify pets."},
+	})
+	op.RequireScopes("oauth2", "pets:write")
+
+The Add*Security helpers use fixed IDs, like "apiKeyAuth".
+To add several schemes of the same kind, like API keys for servers and for webhooks,
+use AddSecurityScheme with your own IDs:
+
+	sw.AddSecurityScheme("serverKey", sashay.SecurityScheme{Type: "apiKey", In: "header", Name: "X-Server-Key"})
+	sw.AddSecurityScheme("webhookKey", sashay.SecurityScheme{
+		Type:        "apiKey",
+		Description: "Key sent in webhook URLs.",
+		In:          "query",
+		Name:        "key",
+	})
+	op.RequireSecurity("webhookKey")
+
+Sashay panics if two schemes use the same ID.
+
+Use AddHTTPSecurity for other HTTP authorization schemes, like Digest or custom HMAC signatures,
+and AddMutualTLSSecurity for mutual TLS.
+mutualTLS is only part of OpenAPI 3.1, so set Sashay.OpenAPIVersion to OpenAPIVersion31 to use it:
+
+	sw.OpenAPIVersion = sashay.OpenAPIVersion31
+	sw.AddHTTPSecurity("hmacAuth", "hmac-sha256", "Requests are signed with the partner secret.")
+	sw.AddMutualTLSSecurity("meshTLS", "Service mesh client certificates.")
+
+Use WithSecurity to set arbitrary SecurityRequirements,
+like an empty SecurityRequirement to make authorization optional.
+Sashay panics if a requirement names a security scheme which has not been added.
+
+# Sashay Detail- Examples
+
+Operations hold zero'd instances of Params, ReturnOk, and ReturnErr.
+If you set Sashay.ExamplesFromValues, you can pass populated instances instead,
+and Sashay will serialize them with encoding/json into examples:
+
+	sw.ExamplesFromValues = true
+	sw.Add(sashay.NewOperation(
+		"GET",
+		"/pets/:id",
+		"Fetch a pet.",
+		struct {
+			ID int `path:"id"`
+		}{ID: 5},
+		Pet{ID: 5, Name: "Spot"},
+		Error{},
+	))
+
+Zero values (like Error{} or []Pet{}) do not get examples.
+Like in schemas, structs (including nested ones) only include their json-named fields,
+and pointers are written as the values they point to.
+Parameters can also use an "example" struct tag, which is written as-is.
+
+Named sets of examples can be added to responses with Response.WithExamples,
+and to request bodies with Operation.WithRequestExamples:
+
+	sashay.NewResponse(200, "The pet.", Pet{}).WithExamples(sashay.Examples{
+		"dog": Pet{ID: 1, Name: "Spot"},
+		"cat": Pet{ID: 2, Name: "Tom"},
+	})
+
+Finally, schemas in components/schemas can have an example, using Sashay.DefineSchemaExample:
+
+	sw.DefineSchemaExample(Pet{ID: 5, Name: "Spot"})
 */
 package sashay
@@ -0,0 +1,126 @@
+package sashay
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Examples is a set of named examples, like {"admin": User{Name: "root", Admin: true}}.
+// Each value is serialized with encoding/json.
+// See https://swagger.io/docs/specification/adding-examples/
+type Examples map[string]interface{}
+
+// Return the names of the examples, sorted.
+func (ex Examples) sortedNames() []string {
+	names := make([]string, 0, len(ex))
+	for name := range ex {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Return the JSON for v, which can be written as a YAML value.
+func exampleJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("Example value %v cannot be serialized to JSON: %s", v, err))
+	}
+	return string(b)
+}
+
+// Return the example JSON for the populated value of f, and true,
+// or an empty string and false if f is nil or a zero value (like an empty struct or slice).
+// Structs only include their json-named fields, like their schemas,
+// and are only populated if one of those fields is.
+func valueExampleJSON(f Field) (string, bool) {
+	if f.Nil() {
+		return "", false
+	}
+	if f.Kind == reflect.Struct && !isExampleLeaf(f) {
+		return structExampleJSON(f)
+	}
+	if isZeroValue(f.Value) {
+		return "", false
+	}
+	return fieldExampleJSON(f), true
+}
+
+// Return the example JSON for the json-named fields of struct f,
+// and true if any of those fields is populated.
+func structExampleJSON(f Field) (string, bool) {
+	parts := make([]string, 0)
+	populated := false
+	for _, field := range enumerateStructFields(f) {
+		name := jsonName(field.StructField)
+		if name == "" {
+			continue
+		}
+		populated = populated || !isZeroValue(field.Value)
+		parts = append(parts, exampleJSON(name)+":"+fieldExampleJSON(field))
+	}
+	return "{" + strings.Join(parts, ",") + "}", populated
+}
+
+// Return the example JSON for f, which is serialized with encoding/json,
+// except that structs (including slice items and map values) only include their json-named fields.
+func fieldExampleJSON(f Field) string {
+	if f.Nil() || (f.Value.Kind() == reflect.Ptr && f.Value.IsNil()) {
+		return "null"
+	}
+	if _, ok := f.Interface.(json.Marshaler); ok {
+		return exampleJSON(f.Interface)
+	}
+	v := reflect.Indirect(f.Value)
+	switch {
+	case f.Kind == reflect.Struct && !isExampleLeaf(f):
+		result, _ := structExampleJSON(f)
+		return result
+	case (f.Kind == reflect.Slice && !isBytesField(f) && !v.IsNil()) || f.Kind == reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fieldExampleJSON(NewField(v.Index(i).Interface()))
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	case f.Kind == reflect.Map && f.Type.Key().Kind() == reflect.String && !v.IsNil():
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		entries := make([]string, len(keys))
+		for i, k := range keys {
+			entries[i] = exampleJSON(k.String()) + ":" + fieldExampleJSON(NewField(v.MapIndex(k).Interface()))
+		}
+		return "{" + strings.Join(entries, ",") + "}"
+	}
+	return exampleJSON(f.Interface)
+}
+
+// Return true if the struct f should be serialized as a whole,
+// because it has no exported fields (like time.Time) or serializes itself.
+func isExampleLeaf(f Field) bool {
+	if f.Type.NumField() == 0 {
+		return true
+	}
+	_, ok := f.Interface.(json.Marshaler)
+	return ok || len(enumerateStructFields(f)) == 0
+}
+
+// Return true if v is the zero value of its type, a nil pointer or pointer to a zero value,
+// or an empty slice or map.
+func isZeroValue(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return true
+		}
+		return isZeroValue(v.Elem())
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
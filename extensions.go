@@ -0,0 +1,64 @@
+package sashay
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Extensions are vendor extensions, like {"x-rate-limit": 100, "x-go-name": "UserID"}.
+// Keys must start with "x-". Values are serialized with encoding/json.
+// See https://swagger.io/docs/specification/openapi-extensions/
+type Extensions map[string]interface{}
+
+// Return the keys of the extensions in sorted order.
+// Panics if any key does not start with "x-".
+func (ext Extensions) sortedKeys() []string {
+	keys := make([]string, 0, len(ext))
+	for k := range ext {
+		if !strings.HasPrefix(k, "x-") {
+			panic(fmt.Sprintf("extension %q must start with x-", k))
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Write the extensions as keys at indent.
+func (b *baseBuilder) writeExtensions(indent int, ext Extensions) {
+	for _, k := range ext.sortedKeys() {
+		b.writeLn(indent, "%s: %s", k, exampleJSON(ext[k]))
+	}
+}
+
+// Write the extensions from the "x-" struct tags of f at indent.
+// Struct tag values are written as-is, like the "example" struct tag,
+// so `x-go-name:"UserID"` is written as "x-go-name: UserID".
+func (b *baseBuilder) writeTagExtensions(indent int, f Field) {
+	for _, kv := range tagExtensions(f.StructField.Tag) {
+		b.writeLn(indent, "%s: %s", kv[0], kv[1])
+	}
+}
+
+// Return the key and value of each struct tag starting with "x-", in the order they are declared.
+func tagExtensions(tag reflect.StructTag) [][2]string {
+	result := make([][2]string, 0)
+	seen := make(map[string]bool)
+	for _, m := range tagExtensionKey.FindAllStringSubmatch(string(tag), -1) {
+		key := m[1]
+		// The pattern can match inside another tag's quoted value, so only keys Lookup finds are used.
+		value, ok := tag.Lookup(key)
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, [2]string{key, value})
+	}
+	return result
+}
+
+// Matches the keys of "x-" struct tags, like x-go-name in `json:"id" x-go-name:"UserID"`.
+var tagExtensionKey = regexp.MustCompile(`(?:^|\s)(x-[^\s:"]+):"`)
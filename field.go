@@ -77,6 +77,16 @@ func ZeroSliceValueField(t reflect.Type) Field {
 	return NewField(r.Interface())
 }
 
+// For a reflect.Type for a slice, array, or map, return a Field representing a zero value of its element type.
+// Return an empty Field if the element type is an interface.
+func zeroElemField(t reflect.Type) Field {
+	elem := t.Elem()
+	if elem.Kind() == reflect.Interface {
+		return Field{}
+	}
+	return NewField(reflect.Zero(elem).Interface())
+}
+
 // Fields is a slice of Field instances.
 type Fields []Field
 
@@ -37,6 +37,44 @@ var _ = Describe("Swagger internals test", func() {
 		})
 	})
 
+	Describe("tagExtensions", func() {
+		It("returns x- struct tags in declaration order", func() {
+			tag := reflect.StructTag(`json:"id" x-go-name:"UserID" query:"id" x-order:"1" x-note:"a \"quoted\" note"`)
+			Expect(tagExtensions(tag)).To(Equal([][2]string{
+				{"x-go-name", "UserID"},
+				{"x-order", "1"},
+				{"x-note", `a "quoted" note`},
+			}))
+		})
+
+		It("returns nothing for tags without extensions", func() {
+			Expect(tagExtensions(`json:"id"`)).To(BeEmpty())
+			Expect(tagExtensions("")).To(BeEmpty())
+			Expect(tagExtensions(`description:"see x-id:" json:"id"`)).To(BeEmpty())
+		})
+	})
+
+	Describe("formName", func() {
+		type Tester struct {
+			Dash   int `form:"-"`
+			None   int
+			Mapped int `form:"mapped,omitempty"`
+		}
+		testerType := reflect.TypeOf(Tester{})
+
+		It("returns empty string for form tag value of - or no form tag", func() {
+			f, _ := testerType.FieldByName("Dash")
+			Expect(formName(f)).To(Equal(""))
+			f, _ = testerType.FieldByName("None")
+			Expect(formName(f)).To(Equal(""))
+		})
+
+		It("returns mapped form name", func() {
+			f, _ := testerType.FieldByName("Mapped")
+			Expect(formName(f)).To(Equal("mapped"))
+		})
+	})
+
 	Describe("isExportedField", func() {
 		It("is true for anonymous fields", func() {
 			type T struct {
@@ -2,9 +2,14 @@ package sashay
 
 import (
 	"bytes"
+	"fmt"
+	"reflect"
 	"regexp"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Operation is the definition for an endpoint (method and path).
@@ -14,23 +19,121 @@ type Operation struct {
 	Method string
 	// Path is the path for the endpoint. Parameters should have a leading colon, like /users/:id.
 	Path string
+	// OperationID is an optional operationId for the endpoint.
+	// If empty, use the Sashay's OperationIDer (by default, NewOperationID).
+	OperationID string
+	// Handler is an optional function handling the endpoint, like a method on a controller.
+	// It is not called; it is used only by OperationIDers like HandlerOperationID.
+	Handler interface{}
 	// Summary is the summary for the endpoint. Please provide it.
 	Summary string
 	// Description is an optional longer endpoint description with Markdown support.
 	Description string
 	// Params is a zero'd instance of parameters for the endpoint.
 	// If there are no params, use nil.
+	// Params which are not structs, like slices and maps, are used as the request body
+	// for any method, like Body is.
 	Params interface{}
+	// Body is an optional zero'd instance of the request body for the endpoint.
+	// It can be any Go value, like a struct, slice, map, string, or []byte.
+	// When Body is nil, the request body is built from the json (or form) fields of Params.
+	// When Body is set, it is used for the request body, regardless of method
+	// (unless RequestBodyUsage is RequestBodyNever), and Params is only used for parameters.
+	Body interface{}
 	// ReturnOk is a zero'ed instance of the struct used for successful responses from the endpoint.
 	// If nil, assume a 204 success and use no body.
 	ReturnOk interface{}
-	// ReturnOk is a zero'ed instance of the struct used for an error response from the endpoint.
+	// ReturnErr is a zero'ed instance of the struct used for an error response from the endpoint.
 	// Since all endpoints should return the same error response shape,
-	// we use thue 'default' Swagger response field. We can add custom error code mapping in the future.
+	// we use the 'default' Swagger response field,
+	// unless the type is mapped to a status code with Sashay#MapErrorCode.
 	ReturnErr interface{}
+	// ErrorResponses are additional error responses for the endpoint,
+	// like a 404 for an endpoint fetching a resource by ID.
+	ErrorResponses Responses
+	// OmitErrorCodes are status codes of document-wide error responses (see Sashay#AddErrorResponse)
+	// that should not be documented for the endpoint, like a 401 for a public endpoint.
+	OmitErrorCodes []int
 	// Tags is a slice of string tags for the operation.
 	// Tags can be used for logical grouping of operations by resources or any other qualifier.
 	Tags []string
+	// RequestBodyUsage controls whether a requestBody is written from Params.
+	// By default, POST, PUT, and PATCH operations get one, and other methods do not.
+	RequestBodyUsage RequestBodyUsage
+	// RequestContentType is the content type of the request body.
+	// If empty, use the Sashay's DefaultContentType
+	// (or the form content type, if the body has "form" tagged fields,
+	// text/plain for a string Body, and application/octet-stream for a []byte Body).
+	// When it is ContentTypeJSONPatch, the body is documented as a JSON Patch document,
+	// rather than from the fields of Params.
+	RequestContentType string
+	// RequestExamples are named examples of the request body.
+	// They are used for every media type of the request body.
+	RequestExamples Examples
+	// RequestMediaTypes are the content types the request body can be sent in,
+	// each optionally with its own shape, like JSON and XML versions of a resource.
+	// If set, they are used instead of RequestContentType.
+	RequestMediaTypes MediaTypes
+	// ResponseContentTypes are the content types for the responses built from ReturnOk,
+	// like application/json and text/csv for an export endpoint.
+	// If empty, use the Sashay's DefaultContentType (or text/plain for string responses).
+	// Responses with their own ContentTypes are not affected.
+	ResponseContentTypes []string
+	// ErrorContentTypes are the content types for the responses built from ReturnErr,
+	// like application/problem+json.
+	// If empty, use the Sashay's DefaultContentType (or text/plain for string responses).
+	// Responses with their own ContentTypes are not affected.
+	ErrorContentTypes []string
+	// ExternalDocs optionally links to more documentation for the endpoint.
+	ExternalDocs ExternalDocs
+	// Deprecated marks the endpoint as deprecated.
+	// It is written as "deprecated: true", and a deprecation note is appended to the description.
+	Deprecated bool
+	// Sunset is the optional date a deprecated endpoint will be removed, which is noted in its description.
+	Sunset time.Time
+	// ReplacedBy optionally describes the endpoint that replaces a deprecated endpoint,
+	// like "GET /v2/users", which is noted in its description.
+	ReplacedBy string
+	// Callbacks are requests the API sends to the client in response to the endpoint,
+	// like to a URL passed in the request body.
+	Callbacks []Callback
+	// Security are the security requirements for the endpoint, any of which can be satisfied.
+	// If nil, the document-wide security (every scheme added to the Sashay) applies.
+	// If empty but not nil, the endpoint is public, and is written with "security: []".
+	Security []SecurityRequirement
+	// Extensions are vendor extensions for the operation, like x-codeSamples.
+	Extensions Extensions
+}
+
+// RequestBodyUsage controls whether an Operation documents a request body.
+type RequestBodyUsage int
+
+const (
+	// RequestBodyDefault uses a request body for POST, PUT, and PATCH operations.
+	RequestBodyDefault RequestBodyUsage = iota
+	// RequestBodyAlways uses a request body for any method, like a DELETE with a body.
+	RequestBodyAlways
+	// RequestBodyNever never uses a request body, even for POST, PUT, and PATCH.
+	RequestBodyNever
+)
+
+// Content types for PATCH request bodies.
+// See https://tools.ietf.org/html/rfc7396 and https://tools.ietf.org/html/rfc6902
+const (
+	ContentTypeMergePatch = "application/merge-patch+json"
+	ContentTypeJSONPatch  = "application/json-patch+json"
+)
+
+// WithOperationID sets the operationId on the receiver and returns a modified instance.
+func (op Operation) WithOperationID(id string) Operation {
+	op.OperationID = id
+	return op
+}
+
+// WithHandler sets the handler function on the receiver and returns a modified instance.
+func (op Operation) WithHandler(handler interface{}) Operation {
+	op.Handler = handler
+	return op
 }
 
 // WithDescription sets the description on the receiver and returns a modified instance.
@@ -45,17 +148,196 @@ func (op Operation) AddTags(tags ...string) Operation {
 	return op
 }
 
+// WithBody sets the request body on the receiver and returns a modified instance.
+func (op Operation) WithBody(body interface{}) Operation {
+	op.Body = body
+	return op
+}
+
+// WithRequestBody sets the receiver to always use a request body, regardless of method,
+// and returns a modified instance.
+func (op Operation) WithRequestBody() Operation {
+	op.RequestBodyUsage = RequestBodyAlways
+	return op
+}
+
+// WithoutRequestBody sets the receiver to never use a request body, regardless of method,
+// and returns a modified instance.
+func (op Operation) WithoutRequestBody() Operation {
+	op.RequestBodyUsage = RequestBodyNever
+	return op
+}
+
+// WithRequestContentType sets the request body content type on the receiver and returns a modified instance.
+func (op Operation) WithRequestContentType(contentType string) Operation {
+	op.RequestContentType = contentType
+	return op
+}
+
+// AddErrorResponses appends error responses to the receiver and returns a modified instance.
+func (op Operation) AddErrorResponses(responses ...Response) Operation {
+	op.ErrorResponses = append(op.ErrorResponses, responses...)
+	return op
+}
+
+// WithoutErrorCodes omits the document-wide error responses with the given status codes
+// from the receiver and returns a modified instance.
+func (op Operation) WithoutErrorCodes(codes ...int) Operation {
+	op.OmitErrorCodes = append(op.OmitErrorCodes, codes...)
+	return op
+}
+
+// WithRequestExamples sets the named request body examples on the receiver and returns a modified instance.
+func (op Operation) WithRequestExamples(examples Examples) Operation {
+	op.RequestExamples = examples
+	return op
+}
+
+// WithRequestMediaTypes sets the request body media types on the receiver and returns a modified instance.
+func (op Operation) WithRequestMediaTypes(mediaTypes ...MediaType) Operation {
+	op.RequestMediaTypes = mediaTypes
+	return op
+}
+
+// WithResponseContentTypes sets the content types of successful responses on the receiver
+// and returns a modified instance.
+func (op Operation) WithResponseContentTypes(contentTypes ...string) Operation {
+	op.ResponseContentTypes = contentTypes
+	return op
+}
+
+// WithErrorContentTypes sets the content types of error responses on the receiver
+// and returns a modified instance.
+func (op Operation) WithErrorContentTypes(contentTypes ...string) Operation {
+	op.ErrorContentTypes = contentTypes
+	return op
+}
+
+// AddCallback appends a callback to the receiver and returns a modified instance.
+// See Callback for the meaning of the arguments.
+// Panics if name or expression is empty.
+func (op Operation) AddCallback(name, expression string, callbackOp Operation) Operation {
+	if callbackOp.Method == "" {
+		callbackOp.Method = "POST"
+	}
+	cb := Callback{Name: name, Expression: expression, Operation: callbackOp}
+	cb.validate()
+	op.Callbacks = append(op.Callbacks, cb)
+	return op
+}
+
+// WithExternalDocs sets the link to external documentation on the receiver and returns a modified instance.
+func (op Operation) WithExternalDocs(url, description string) Operation {
+	op.ExternalDocs = ExternalDocs{URL: url, Description: description}
+	return op
+}
+
+// WithExtensions sets the vendor extensions on the receiver and returns a modified instance.
+func (op Operation) WithExtensions(ext Extensions) Operation {
+	op.Extensions = ext
+	return op
+}
+
+// WithDeprecated marks the receiver as deprecated and returns a modified instance.
+func (op Operation) WithDeprecated() Operation {
+	op.Deprecated = true
+	return op
+}
+
+// WithSunset marks the receiver as deprecated, to be removed on the sunset date,
+// and returns a modified instance.
+func (op Operation) WithSunset(sunset time.Time) Operation {
+	op.Deprecated = true
+	op.Sunset = sunset
+	return op
+}
+
+// WithReplacement marks the receiver as deprecated, replaced by the given Operation,
+// and returns a modified instance.
+func (op Operation) WithReplacement(replacement Operation) Operation {
+	op.Deprecated = true
+	op.ReplacedBy = strings.ToUpper(replacement.Method) + " " + string(NewPath(replacement.Path))
+	return op
+}
+
+// Return the description of the operation, with a deprecation note if it is deprecated.
+func (op Operation) description() string {
+	if !op.Deprecated {
+		return op.Description
+	}
+	note := "This operation is deprecated"
+	if !op.Sunset.IsZero() {
+		note += " and will be removed on " + op.Sunset.Format("2006-01-02")
+	}
+	note += "."
+	if op.ReplacedBy != "" {
+		note += " Use " + op.ReplacedBy + " instead."
+	}
+	if op.Description == "" {
+		return note
+	}
+	return op.Description + " " + note
+}
+
+// Public marks the receiver as not requiring any security, overriding the document-wide security,
+// and returns a modified instance.
+func (op Operation) Public() Operation {
+	op.Security = []SecurityRequirement{}
+	return op
+}
+
+// RequireSecurity sets the receiver to require any one of the security schemes with the given IDs (OR),
+// and returns a modified instance.
+func (op Operation) RequireSecurity(ids ...string) Operation {
+	op.Security = make([]SecurityRequirement, 0, len(ids))
+	for _, id := range ids {
+		op.Security = append(op.Security, SecurityRequirement{id: {}})
+	}
+	return op
+}
+
+// RequireAllSecurity adds a requirement to the receiver that all of the security schemes
+// with the given IDs are used together (AND), and returns a modified instance.
+// Calling it more than once allows any one of the combinations, like
+// op.RequireAllSecurity("apiKeyAuth", "bearerAuth").RequireAllSecurity("basicAuth").
+func (op Operation) RequireAllSecurity(ids ...string) Operation {
+	req := make(SecurityRequirement, len(ids))
+	for _, id := range ids {
+		req[id] = []string{}
+	}
+	op.Security = append(op.Security, req)
+	return op
+}
+
+// RequireScopes adds a requirement to the receiver for the security scheme with the given ID,
+// like "oauth2", with the given scopes, and returns a modified instance.
+// Calling it more than once allows any one of the requirements (OR).
+func (op Operation) RequireScopes(id string, scopes ...string) Operation {
+	op.Security = append(op.Security, SecurityRequirement{id: scopes})
+	return op
+}
+
+// WithSecurity sets the security requirements on the receiver and returns a modified instance.
+func (op Operation) WithSecurity(reqs ...SecurityRequirement) Operation {
+	op.Security = reqs
+	if op.Security == nil {
+		op.Security = []SecurityRequirement{}
+	}
+	return op
+}
+
 func (op Operation) toInternalOperation() internalOperation {
 	return internalOperation{
 		op,
 		NewMethod(op.Method),
 		NewPath(op.Path),
-		NewOperationID(op),
 		op.Summary,
-		op.Description,
+		op.description(),
 		NewField(op.Params),
+		NewField(op.Body),
 		op.responses(),
 		op.Tags,
+		op,
 	}
 }
 
@@ -85,7 +367,9 @@ func (op Operation) responses() Responses {
 		}
 		responses = append(responses, NewResponse(code, desc, op.ReturnOk))
 	}
+	responses.defaultContentTypes(0, op.ResponseContentTypes)
 
+	okCount := len(responses)
 	switch returnErr := op.ReturnErr.(type) {
 	case Responses:
 		responses = append(responses, returnErr...)
@@ -94,6 +378,8 @@ func (op Operation) responses() Responses {
 	default:
 		responses = append(responses, NewResponse(-1, "error response", op.ReturnErr))
 	}
+	responses = append(responses, op.ErrorResponses...)
+	responses.defaultContentTypes(okCount, op.ErrorContentTypes)
 
 	return responses
 }
@@ -118,6 +404,35 @@ type Response struct {
 	Code        string
 	Description string
 	Field       Field
+	// ContentTypes are the content types the response can be returned in.
+	// Each uses the same schema.
+	// If empty, use the Operation's content types, or the Sashay's DefaultContentType
+	// (or text/plain for string responses).
+	ContentTypes []string
+	// MediaTypes are the content types the response can be returned in,
+	// each optionally with its own shape, like JSON, CSV, and NDJSON versions of a resource.
+	// If set, they are used instead of ContentTypes.
+	MediaTypes MediaTypes
+	// Headers is a zero'd instance of a struct describing the response headers,
+	// using "header" struct tags like Params does, such as:
+	//
+	//	struct {
+	//		Location string `header:"Location" description:"URL of the new resource." required:"true"`
+	//	}{}
+	//
+	// If there are no headers, it is a nil Field.
+	Headers Field
+	// Examples are named examples of the response.
+	// They are used for every media type of the response.
+	Examples Examples
+	// Ref is the name of a reusable response defined with Sashay#DefineResponse.
+	// If set, the response is written as a $ref to it, and all other fields except Code are ignored.
+	Ref string
+	// Links describe how values from the response can be used for requests to other Operations,
+	// like using the id of a created user to get the user.
+	Links []Link
+	// Extensions are vendor extensions for the response.
+	Extensions Extensions
 }
 
 // NewResponse returns a new Response initialized with the given code and description.
@@ -131,12 +446,156 @@ func NewResponse(code int, description string, shape interface{}) Response {
 	} else {
 		strcode = strconv.Itoa(code)
 	}
-	return Response{strcode, description, NewField(shape)}
+	return Response{Code: strcode, Description: description, Field: NewField(shape)}
+}
+
+// NewResponseRef returns a new Response for the status code that references the reusable response
+// with the given name, defined with Sashay#DefineResponse.
+// code is an HTTP status code, or -1 for "default".
+func NewResponseRef(code int, name string) Response {
+	resp := NewResponse(code, "", nil)
+	resp.Ref = name
+	return resp
+}
+
+// WithContentTypes sets the content types on the receiver and returns a modified instance.
+func (r Response) WithContentTypes(contentTypes ...string) Response {
+	r.ContentTypes = contentTypes
+	return r
+}
+
+// WithMediaTypes sets the media types on the receiver and returns a modified instance.
+func (r Response) WithMediaTypes(mediaTypes ...MediaType) Response {
+	r.MediaTypes = mediaTypes
+	return r
+}
+
+// WithExamples sets the named examples on the receiver and returns a modified instance.
+func (r Response) WithExamples(examples Examples) Response {
+	r.Examples = examples
+	return r
+}
+
+// AddLinks appends links to the receiver and returns a modified instance.
+func (r Response) AddLinks(links ...Link) Response {
+	r.Links = append(r.Links, links...)
+	return r
+}
+
+// WithExtensions sets the vendor extensions on the receiver and returns a modified instance.
+func (r Response) WithExtensions(ext Extensions) Response {
+	r.Extensions = ext
+	return r
+}
+
+// WithHeaders sets the response headers on the receiver and returns a modified instance.
+// headers should be a struct with "header" tagged fields (see Response.Headers).
+func (r Response) WithHeaders(headers interface{}) Response {
+	r.Headers = NewField(headers)
+	return r
+}
+
+// Link describes how values from a response can be used for a request to another Operation.
+// The target Operation must be added to the Sashay.
+// See https://swagger.io/docs/specification/links/
+type Link struct {
+	// Name identifies the link, like "GetUserByID".
+	Name string
+	// Description is an optional description of the link.
+	Description string
+	// Operation is the target of the link.
+	// It is found by its method and path in the Operations added to the Sashay.
+	// If nil, OperationID is used.
+	Operation *Operation
+	// OperationID is the operationId of the target of the link, if Operation is nil.
+	OperationID string
+	// Parameters maps the names of the target's parameters to their values,
+	// which are usually runtime expressions, like "$response.body#/id".
+	Parameters map[string]string
+}
+
+// NewLink returns a Link to the target Operation,
+// with parameters mapping the names of the target's parameters to their values.
+func NewLink(name string, target Operation, parameters map[string]string) Link {
+	return Link{Name: name, Operation: &target, Parameters: parameters}
+}
+
+// NewLinkToID returns a Link to the Operation with the given operationId,
+// with parameters mapping the names of the target's parameters to their values.
+func NewLinkToID(name, operationID string, parameters map[string]string) Link {
+	return Link{Name: name, OperationID: operationID, Parameters: parameters}
+}
+
+// Return the link's parameter names in sorted order.
+func (l Link) sortedParameters() []string {
+	names := make([]string, 0, len(l.Parameters))
+	for name := range l.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // Responses is a slice of Response objects.
 type Responses []Response
 
+// Set the ContentTypes of responses, from index start on, to contentTypes,
+// if they do not already have content types.
+func (rs Responses) defaultContentTypes(start int, contentTypes []string) {
+	if len(contentTypes) == 0 {
+		return
+	}
+	for i := start; i < len(rs); i++ {
+		if len(rs[i].ContentTypes) == 0 {
+			rs[i].ContentTypes = contentTypes
+		}
+	}
+}
+
+// MediaType is a content type and the shape of the body for it,
+// for when a request body or response can use multiple content types with different schemas.
+// See https://swagger.io/specification/#mediaTypeObject
+type MediaType struct {
+	ContentType string
+	// Field is the shape of the body for this content type.
+	// If it is nil, use the shape of the Response or request body the MediaType is for.
+	Field Field
+}
+
+// NewMediaType returns a new MediaType for the given content type.
+// shape should be the body object, like User{} or "" for a text body,
+// or nil to use the shape of the Response or request body the MediaType is for.
+func NewMediaType(contentType string, shape interface{}) MediaType {
+	return MediaType{ContentType: contentType, Field: NewField(shape)}
+}
+
+// MediaTypes is a slice of MediaType objects.
+type MediaTypes []MediaType
+
+// Callback is a request the API sends to the client in response to an Operation.
+// See https://swagger.io/docs/specification/callbacks/
+type Callback struct {
+	// Name identifies the callback, like "onData".
+	Name string
+	// Expression is the URL the callback is sent to, which is usually a runtime expression,
+	// like "{$request.body#/callbackUrl}".
+	Expression string
+	// Operation describes the callback request, like Operations do for requests the API receives:
+	// its Params or Body is the callback payload, and ReturnOk and ReturnErr are the responses it expects.
+	// Its Path is not used, and its OperationID is only written if it is set.
+	Operation Operation
+}
+
+// Panic if the callback is missing its name or expression.
+func (cb Callback) validate() {
+	if cb.Name == "" {
+		panic(fmt.Sprintf("callback for expression %q must have a name", cb.Expression))
+	}
+	if cb.Expression == "" {
+		panic(fmt.Sprintf("callback %q must have an expression", cb.Name))
+	}
+}
+
 // Method represents an HTTP method string ("get", "post", etc.).
 type Method string
 
@@ -161,7 +620,8 @@ var swaggerPathReplace = regexp.MustCompile("/:([A-Za-z0-9]+)")
 // OperationID represents a Swagger operationId string.
 type OperationID string
 
-// NewOperationID returns an OperationID that is unique for the method and endpoint.
+// NewOperationID returns an OperationID derived from the method and endpoint.
+// Paths which differ only by punctuation, like /users/:id and /users/id, get the same OperationID.
 func NewOperationID(op Operation) OperationID {
 	bu := bytes.NewBuffer(nil)
 	bu.WriteString(strings.ToLower(op.Method))
@@ -182,23 +642,78 @@ func NewOperationID(op Operation) OperationID {
 
 var operationIDPathClean = regexp.MustCompile("[^A-Za-z0-9_]")
 
+// OperationIDer returns the OperationID for an Operation which does not set its own OperationID.
+type OperationIDer func(op Operation) OperationID
+
+// HandlerOperationID is an OperationIDer that uses the name of the Operation's Handler function,
+// with a lowercase first letter.
+// For example, a Handler of UsersController.GetUser has an OperationID of "getUser".
+// If the Operation has no Handler, or it is an anonymous function, use NewOperationID.
+func HandlerOperationID(op Operation) OperationID {
+	if op.Handler == nil {
+		return NewOperationID(op)
+	}
+	v := reflect.ValueOf(op.Handler)
+	if v.Kind() != reflect.Func {
+		panic(fmt.Sprintf("Handler for %s %s must be a function, got %T", op.Method, op.Path, op.Handler))
+	}
+	// Names look like "github.com/me/api.(*UsersController).GetUser-fm" for method values,
+	// "github.com/me/api.getUser" for functions, "github.com/me/api.getUser[...]" for generic functions,
+	// and "github.com/me/api.init.func1" for anonymous functions.
+	name := runtime.FuncForPC(v.Pointer()).Name()
+	name = strings.TrimSuffix(name, "-fm")
+	name = strings.TrimSuffix(name, "[...]")
+	name = name[strings.LastIndex(name, ".")+1:]
+	if anonymousFuncName.MatchString(name) {
+		return NewOperationID(op)
+	}
+	return OperationID(strings.ToLower(name[0:1]) + name[1:])
+}
+
+var anonymousFuncName = regexp.MustCompile(`^func\d+$`)
+
 // internalOperation wraps stuff in Field and Responses so we don't have to do it inline,
 // and can use consistent interfaces in our internal code.
 type internalOperation struct {
 	Original    Operation
 	Method      Method
 	Path        Path
-	OperationID OperationID
 	Summary     string
 	Description string
 	Params      Field
+	Body        Field
 	Responses   Responses
 	Tags        []string
+	// Source is the Operation as it was added, before any SelectMap function modified it.
+	Source Operation
 }
 
 // True if a requestBody section is needed for the object.
-// POST and PUT operations should get this section if any params are defined,
-// otherwise it should be false (GET, DELETE etc should never use request bodies).
+// POST, PUT, and PATCH operations should get this section if any params are defined,
+// otherwise it should be false (GET, DELETE etc should not use request bodies),
+// unless the Operation's RequestBodyUsage says otherwise.
+// Operations with an explicit Body, or non-struct Params, always get this section,
+// unless RequestBodyUsage is RequestBodyNever.
 func (o internalOperation) useRequestBody() bool {
-	return (o.Method == "post" || o.Method == "put") && !o.Params.Nil()
+	if o.bodyField().Nil() {
+		return false
+	}
+	switch o.Original.RequestBodyUsage {
+	case RequestBodyAlways:
+		return true
+	case RequestBodyNever:
+		return false
+	}
+	if !o.Body.Nil() || o.Params.Kind != reflect.Struct {
+		return true
+	}
+	return o.Method == "post" || o.Method == "put" || o.Method == "patch"
+}
+
+// Return the Field the request body is built from: Body if it is set, Params otherwise.
+func (o internalOperation) bodyField() Field {
+	if !o.Body.Nil() {
+		return o.Body
+	}
+	return o.Params
 }
@@ -31,3 +31,22 @@ func ExampleNewOperationID() {
 	// Output:
 	// getUsersId
 }
+
+type usersController struct{}
+
+func (usersController) GetUser() {}
+
+func listUsers[T any]() {}
+
+func ExampleHandlerOperationID() {
+	op := sashay.NewOperation("GET", "/users/:id", "", nil, nil, nil)
+	fmt.Println(sashay.HandlerOperationID(op.WithHandler(usersController{}.GetUser)))
+	fmt.Println(sashay.HandlerOperationID(op.WithHandler(listUsers[int])))
+	fmt.Println(sashay.HandlerOperationID(op.WithHandler(func() {})))
+	fmt.Println(sashay.HandlerOperationID(op))
+	// Output:
+	// getUser
+	// listUsers
+	// getUsersId
+	// getUsersId
+}
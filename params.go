@@ -0,0 +1,159 @@
+package sashay
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// param is a parameter parsed from a struct field with a "path", "query", "header", or "cookie" tag.
+type param struct {
+	name, in string
+	field    Field
+}
+
+// Return true if p and other are the same parameter, declared the same way
+// (same name, location, type, and struct tag).
+func (p param) same(other param) bool {
+	return p.name == other.name &&
+		p.in == other.in &&
+		p.field.Type == other.field.Type &&
+		p.field.StructField.Tag == other.field.StructField.Tag
+}
+
+// Return true if any of params is the same as p.
+func containsParam(params []param, p param) bool {
+	for _, other := range params {
+		if p.same(other) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the parameters for the tagged fields of struct f.
+// Non-struct values have no parameters, they are only used for the request body.
+func paramsOf(f Field) []param {
+	result := make([]param, 0)
+	if f.Kind != reflect.Struct {
+		return result
+	}
+	for _, field := range enumerateStructFields(f) {
+		tag := field.StructField.Tag
+		for _, in := range paramLocations {
+			if name := tag.Get(in); name != "" {
+				result = append(result, param{name, in, field})
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Return true if params has a param with the given name.
+// The name can be qualified by its location, like "path.id", as in link parameters;
+// otherwise the param can be in any location.
+func containsParamNamed(params []param, name string) bool {
+	in := ""
+	for _, loc := range paramLocations {
+		if strings.HasPrefix(name, loc+".") {
+			in, name = loc, strings.TrimPrefix(name, loc+".")
+			break
+		}
+	}
+	for _, p := range params {
+		if p.name == name && (in == "" || p.in == in) {
+			return true
+		}
+	}
+	return false
+}
+
+var paramLocations = []string{"path", "query", "header", "cookie"}
+
+// ParamRequirer returns true if the parameter for the Field f should be marked as required.
+// It is consulted for query, header, and cookie parameters that do not specify
+// their required-ness through struct tags.
+// Path parameters are always required.
+type ParamRequirer func(f Field) bool
+
+// OptionalParams is the default ParamRequirer.
+// Parameters are only required if their struct tags say so.
+func OptionalParams(_ Field) bool {
+	return false
+}
+
+// NonPointerParamsRequired is a ParamRequirer that marks all non-pointer parameters as required.
+// Use it when pointer fields are how your handlers represent optional parameters.
+func NonPointerParamsRequired(f Field) bool {
+	return f.Value.Kind() != reflect.Ptr
+}
+
+// Return true if the parameter for field, which is in the given location (path, query, etc),
+// should be marked as required.
+// Path parameters are always required. Otherwise, a `required:"true"` or `required:"false"` tag
+// is used if present, then a "required" validation in the `validate` tag,
+// and finally the policy (which may be nil).
+func paramRequired(field Field, in string, policy ParamRequirer) bool {
+	if in == "path" {
+		return true
+	}
+	tag := field.StructField.Tag
+	if req, ok := tag.Lookup("required"); ok {
+		return req == "true"
+	}
+	for _, v := range strings.Split(tag.Get("validate"), ",") {
+		if v == "required" {
+			return true
+		}
+	}
+	if policy == nil {
+		return false
+	}
+	return policy(field)
+}
+
+// paramStyles maps each parameter location to the serialization styles it supports.
+// See https://swagger.io/docs/specification/serialization/
+var paramStyles = map[string][]string{
+	"path":   {"simple", "label", "matrix"},
+	"query":  {"form", "spaceDelimited", "pipeDelimited", "deepObject"},
+	"header": {"simple"},
+	"cookie": {"form"},
+}
+
+// Return the style and explode values for the parameter for field in the given location,
+// from the `style` and `explode` struct tags.
+// Struct-typed query parameters (which are not mapped to a data type) default to
+// deepObject style with explode, like ?filter[name]=x&filter[age]=5.
+// Empty strings mean the value should not be written (so the OpenAPI default is used).
+// Panic if the style is not supported for the location, or explode is not a boolean.
+func paramSerialization(field Field, in string, isObject bool) (style string, explode string) {
+	tag := field.StructField.Tag
+	style = tag.Get("style")
+	explode = tag.Get("explode")
+	if style == "" && isObject && in == "query" {
+		style = "deepObject"
+		if explode == "" {
+			explode = "true"
+		}
+	}
+	if style != "" && !containsString(paramStyles[in], style) {
+		panic(fmt.Sprintf("Parameter %s in %s cannot use style %s. Supported styles are: %s.",
+			field.StructField.Name, in, style, strings.Join(paramStyles[in], ", ")))
+	}
+	if explode != "" && explode != "true" && explode != "false" {
+		panic(fmt.Sprintf("Parameter %s has an explode tag of %s. It must be true or false.",
+			field.StructField.Name, explode))
+	}
+	return style, explode
+}
+
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
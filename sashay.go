@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"os"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -15,17 +19,54 @@ import (
 // See https://swagger.io/specification/
 type Sashay struct {
 	// The default content type for all request bodies and responses.
-	// Defaults to application/json. This can only be set document-wide,
-	// and cannot vary per-endpoint right now.
-	DefaultContentType                    string
+	// Defaults to application/json.
+	// Operations and Responses can override it with their own content types.
+	DefaultContentType string
+	// ParamRequirer decides if query, header, and cookie parameters without
+	// a required struct tag are required. Defaults to OptionalParams.
+	// Use NonPointerParamsRequired to treat all non-pointer parameters as required.
+	ParamRequirer ParamRequirer
+	// OperationIDer returns the operationId for Operations which do not set their own OperationID.
+	// Defaults to NewOperationID, which uses the method and path.
+	// Use HandlerOperationID to use the name of the Operation's Handler.
+	OperationIDer OperationIDer
+	// RequestBodyRefs controls whether JSON request bodies for exported, named Params structs
+	// are written inline (the default), or as components referenced with $ref.
+	RequestBodyRefs RequestBodyRefs
+	// ExamplesFromValues controls whether populated (non-zero) values for Params, Body,
+	// and responses are serialized into examples.
+	// For example, if it is true, a ReturnOk of User{Name: "Spot"} would have an
+	// example of {"name":"Spot"}, and a zero'd User{} would have no example.
+	ExamplesFromValues bool
+	// ShareCommonParams controls whether parameters declared the same way by all Operations on a path
+	// are written once, in the path's parameters, rather than in each Operation.
+	// It only applies to paths with more than one Operation.
+	ShareCommonParams bool
+	// OpenAPIVersion is the version of the OpenAPI specification the document is written for.
+	// Defaults to OpenAPIVersion30. Some features, like mutualTLS security, need OpenAPIVersion31.
+	OpenAPIVersion string
+	// Extensions are vendor extensions at the root of the document, like x-tagGroups.
+	Extensions Extensions
+	// InfoExtensions are vendor extensions in the info of the document, like x-logo.
+	InfoExtensions                        Extensions
 	title, desc, version                  string
 	operations                            []internalOperation
-	servers                               []swaggerServer
+	removedOperations                     []internalOperation
+	servers                               []Server
 	securities                            []swaggerSecurity
 	tos                                   string
 	contactName, contactURL, contactEmail string
 	licenseName, licenseURL               string
-	tags                                  []swaggerTag
+	externalDocs                          ExternalDocs
+	tags                                  []Tag
+	errorResponses                        Responses
+	namedResponses                        []namedResponse
+	sharedResponses                       []sharedResponse
+	errorCodes                            map[reflect.Type]Response
+	schemaExamples                        map[reflect.Type]interface{}
+	schemaExtensions                      map[reflect.Type]Extensions
+	sharedPathParams                      []sharedPathParams
+	webhooks                              []webhook
 	dataTypesForTypes                     map[reflect.Type]dataTypeDef
 	dataTypesForKinds                     map[reflect.Kind]dataTypeDef
 }
@@ -36,12 +77,19 @@ type Sashay struct {
 func New(title, description, version string) *Sashay {
 	sw := &Sashay{
 		DefaultContentType: "application/json",
+		ParamRequirer:      OptionalParams,
+		OperationIDer:      NewOperationID,
+		OpenAPIVersion:     OpenAPIVersion30,
 		title:              title,
 		desc:               description,
 		version:            version,
 		operations:         make([]internalOperation, 0),
-		servers:            make([]swaggerServer, 0),
+		servers:            make([]Server, 0),
 		securities:         make([]swaggerSecurity, 0),
+		errorResponses:     make(Responses, 0),
+		errorCodes:         make(map[reflect.Type]Response),
+		schemaExamples:     make(map[reflect.Type]interface{}),
+		schemaExtensions:   make(map[reflect.Type]Extensions),
 		dataTypesForTypes:  make(map[reflect.Type]dataTypeDef),
 		dataTypesForKinds:  make(map[reflect.Kind]dataTypeDef),
 	}
@@ -53,6 +101,113 @@ func New(title, description, version string) *Sashay {
 	return sw
 }
 
+// Versions of the OpenAPI specification for Sashay.OpenAPIVersion.
+const (
+	OpenAPIVersion30 = "3.0.0"
+	OpenAPIVersion31 = "3.1.0"
+)
+
+// Return true if the document is written for OpenAPI 3.1.
+func (sa *Sashay) isOpenAPI31() bool {
+	return strings.HasPrefix(sa.OpenAPIVersion, "3.1.")
+}
+
+// RequestBodyRefs controls how request bodies for exported, named Params structs are written.
+type RequestBodyRefs int
+
+const (
+	// RequestBodyInline writes the schema of all request bodies inline.
+	RequestBodyInline RequestBodyRefs = iota
+	// RequestBodySchemaRefs writes the request body struct into components/schemas,
+	// and the requestBody schema is a $ref to it.
+	RequestBodySchemaRefs
+	// RequestBodyComponentRefs writes the request body struct into components/schemas,
+	// and a request body referencing it into components/requestBodies.
+	// The operation's requestBody is a $ref to that request body.
+	// The content type of the request body component comes from the first operation using it.
+	RequestBodyComponentRefs
+)
+
+// Return true if the request body schema for f should be written as a $ref to components/schemas,
+// rather than inline. Only JSON-style bodies of exported named structs can be referenced;
+// anonymous structs and form bodies are always written inline.
+func (sa *Sashay) refBodyStruct(f Field) bool {
+	if sa.RequestBodyRefs == RequestBodyInline {
+		return false
+	}
+	if f.Kind != reflect.Struct || f.Type.Name() == "" || f.Type.PkgPath() == "" {
+		return false
+	}
+	return isExportedName(f.Type.Name()) && !sa.isMappedToDataType(f) && !hasFormFields(f)
+}
+
+// Return true if the request body for op can be written into components/requestBodies.
+func (sa *Sashay) componentRequestBody(op internalOperation) bool {
+	if sa.RequestBodyRefs != RequestBodyComponentRefs || len(op.Original.RequestMediaTypes) > 0 {
+		return false
+	}
+	return sa.refBodyStruct(op.bodyField()) && sa.requestContentType(op) != ContentTypeJSONPatch
+}
+
+// Return the content type of the components/requestBodies entry for each body type,
+// which is the content type of the first Operation added with a body of that type.
+func (sa *Sashay) componentRequestContentTypes() map[reflect.Type]string {
+	result := make(map[reflect.Type]string)
+	ops := make([]internalOperation, 0, len(sa.operations))
+	ops = append(ops, sa.operations...)
+	for _, op := range append(ops, sa.outboundOperations()...) {
+		if !op.useRequestBody() || !sa.componentRequestBody(op) {
+			continue
+		}
+		if _, ok := result[op.bodyField().Type]; !ok {
+			result[op.bodyField().Type] = sa.requestContentType(op)
+		}
+	}
+	return result
+}
+
+// Return the media types for the request body of op,
+// which are the Operation's RequestMediaTypes if set,
+// or the body with its content type (see requestContentType).
+// Each MediaType with no shape uses the body of op.
+func (sa *Sashay) requestMediaTypes(op internalOperation) MediaTypes {
+	if len(op.Original.RequestMediaTypes) == 0 {
+		return MediaTypes{{ContentType: sa.requestContentType(op), Field: op.bodyField()}}
+	}
+	result := make(MediaTypes, 0, len(op.Original.RequestMediaTypes))
+	for _, mt := range op.Original.RequestMediaTypes {
+		if mt.Field.Nil() {
+			mt.Field = op.bodyField()
+		}
+		result = append(result, mt)
+	}
+	return result
+}
+
+// Return the content type for the request body of op.
+func (sa *Sashay) requestContentType(op internalOperation) string {
+	if op.Original.RequestContentType != "" {
+		return op.Original.RequestContentType
+	}
+	body := op.bodyField()
+	if hasFormFields(body) {
+		return formContentType(body)
+	}
+	if body.Kind == reflect.String {
+		return "text/plain"
+	}
+	if isBytesField(body) {
+		return "application/octet-stream"
+	}
+	return sa.DefaultContentType
+}
+
+// Content types used for request bodies with form-encoded fields (see the "form" struct tag).
+const (
+	ContentTypeFormURLEncoded = "application/x-www-form-urlencoded"
+	ContentTypeMultipartForm  = "multipart/form-data"
+)
+
 // BuiltinDataTypeValues is a slice of values of all supported data types.
 // Use it for when you want to define custom DataTypers for the builtin types,
 // like if you are parsing validations.
@@ -65,26 +220,88 @@ var BuiltinDataTypeValues = []interface{}{
 	float64(0),
 	float32(0),
 	time.Time{},
+	multipart.FileHeader{},
 	make(map[string]interface{}, 0),
 	make([]map[string]interface{}, 0),
 	make([]interface{}, 0),
 }
 
 // Add registers a Swagger operations and all the associated types.
+// Panics if any of its Callbacks is missing its name or expression.
 func (sa *Sashay) Add(op Operation) Operation {
+	for _, cb := range op.Callbacks {
+		cb.validate()
+	}
 	sa.operations = append(sa.operations, op.toInternalOperation())
 	return op
 }
 
+// AddWebhook adds a webhook with the given name, like "newPet", which the API sends to its clients.
+// op describes the request the API sends, like Operations do for requests the API receives:
+// its Params or Body is the webhook payload, and ReturnOk and ReturnErr are the responses it expects.
+// Its Path is not used, and its Method defaults to POST.
+// Webhooks are written into the top-level webhooks, which need OpenAPIVersion 3.1;
+// use Operation#AddCallback for OpenAPI 3.0.
+// Panics if name is empty.
+// See https://spec.openapis.org/oas/v3.1.0#oasWebhooks
+func (sa *Sashay) AddWebhook(name string, op Operation) *Sashay {
+	if name == "" {
+		panic("webhook must have a name")
+	}
+	if op.Method == "" {
+		op.Method = "POST"
+	}
+	sa.webhooks = append(sa.webhooks, webhook{name, op.toInternalOperation()})
+	return sa
+}
+
+type webhook struct {
+	name string
+	op   internalOperation
+}
+
+// Return the operations for requests the API sends, rather than receives,
+// which are the webhooks and the callbacks of all operations (and of the callbacks themselves).
+func (sa *Sashay) outboundOperations() []internalOperation {
+	result := make([]internalOperation, 0)
+	var addCallbacks func(op Operation)
+	addCallbacks = func(op Operation) {
+		for _, cb := range op.Callbacks {
+			result = append(result, cb.Operation.toInternalOperation())
+			addCallbacks(cb.Operation)
+		}
+	}
+	for _, wh := range sa.webhooks {
+		result = append(result, wh.op)
+		addCallbacks(wh.op.Original)
+	}
+	for _, op := range sa.operations {
+		addCallbacks(op.Original)
+	}
+	return result
+}
+
 // AddServer adds a server to the swagger file.
 // See https://swagger.io/specification/#serverObject
 func (sa *Sashay) AddServer(url, description string) *Sashay {
-	sa.servers = append(sa.servers, swaggerServer{url, description})
+	return sa.DefineServer(Server{URL: url, Description: description})
+}
+
+// DefineServer adds a server to the swagger file.
+// Unlike AddServer, it can set all of the Server's fields, like its Extensions.
+// See https://swagger.io/specification/#serverObject
+func (sa *Sashay) DefineServer(server Server) *Sashay {
+	sa.servers = append(sa.servers, server)
 	return sa
 }
 
-type swaggerServer struct {
-	url, desc string
+// Server is a server for the API, added with Sashay#DefineServer.
+// See https://swagger.io/specification/#serverObject
+type Server struct {
+	URL         string
+	Description string
+	// Extensions are vendor extensions for the server.
+	Extensions Extensions
 }
 
 // SetTermsOfService sets the termsOfService in the swagger file info.
@@ -103,6 +320,13 @@ func (sa *Sashay) SetContact(name, url, email string) *Sashay {
 	return sa
 }
 
+// SetExternalDocs sets the externalDocs of the swagger file, like a link to guides for the API.
+// See https://swagger.io/specification/#externalDocumentationObject
+func (sa *Sashay) SetExternalDocs(url, description string) *Sashay {
+	sa.externalDocs = ExternalDocs{URL: url, Description: description}
+	return sa
+}
+
 // SetLicense sets the license fields in the swagger file info.
 // See https://swagger.io/specification/#licenseObject
 func (sa *Sashay) SetLicense(name, url string) *Sashay {
@@ -111,56 +335,324 @@ func (sa *Sashay) SetLicense(name, url string) *Sashay {
 	return sa
 }
 
+// AddTag adds a tag with the given name and description to the swagger file.
+// See https://swagger.io/specification/#tagObject
 func (sa *Sashay) AddTag(name, desc string) *Sashay {
-	sa.tags = append(sa.tags, swaggerTag{name: name, desc: desc})
+	return sa.DefineTag(Tag{Name: name, Description: desc})
+}
+
+// DefineTag adds a tag to the swagger file.
+// Unlike AddTag, it can set all of the Tag's fields, like its Extensions.
+// See https://swagger.io/specification/#tagObject
+func (sa *Sashay) DefineTag(tag Tag) *Sashay {
+	sa.tags = append(sa.tags, tag)
+	return sa
+}
+
+// ExternalDocs is a link to documentation outside of the swagger file, like a guide or runbook.
+// It is only written if URL or Description is set.
+// See https://swagger.io/specification/#externalDocumentationObject
+type ExternalDocs struct {
+	URL         string
+	Description string
+}
+
+// Tag is a tag for grouping Operations, added with Sashay#DefineTag.
+// See https://swagger.io/specification/#tagObject
+type Tag struct {
+	Name        string
+	Description string
+	// ExternalDocs optionally links to more documentation for the tag.
+	ExternalDocs ExternalDocs
+	// Extensions are vendor extensions for the tag, like x-displayName.
+	Extensions Extensions
+}
+
+// AddPathParams adds parameters that are shared by all Operations on paths starting with pathPrefix,
+// like AddPathParams("/orgs/:org_id", struct{ OrgID string `path:"org_id"` }{}).
+// params should be a struct with tagged fields, like Operation.Params.
+// The parameters are written once, in each matching path's parameters.
+// Operations declaring the same parameter the same way do not repeat it,
+// while Operations declaring it differently (like with a different description) override it.
+func (sa *Sashay) AddPathParams(pathPrefix string, params interface{}) *Sashay {
+	prefix := Path(strings.TrimSuffix(string(NewPath(pathPrefix)), "/"))
+	sa.sharedPathParams = append(sa.sharedPathParams, sharedPathParams{prefix, paramsOf(NewField(params))})
+	return sa
+}
+
+type sharedPathParams struct {
+	prefix Path
+	params []param
+}
+
+// Return true if the shared parameters apply to path,
+// because path is the same as, or a child of, the prefix.
+func (spp sharedPathParams) appliesTo(path Path) bool {
+	return path == spp.prefix || strings.HasPrefix(string(path), string(spp.prefix)+"/")
+}
+
+// Return the parameters written for path, which are shared by the Operations on it.
+// These are the parameters added with AddPathParams for the path,
+// and, if ShareCommonParams is true, the parameters declared the same way by all of the path's operations.
+func (sa *Sashay) pathParams(path Path, ops []internalOperation) []param {
+	result := make([]param, 0)
+	for _, spp := range sa.sharedPathParams {
+		if !spp.appliesTo(path) {
+			continue
+		}
+		for _, p := range spp.params {
+			if !containsParam(result, p) {
+				result = append(result, p)
+			}
+		}
+	}
+	if !sa.ShareCommonParams {
+		return result
+	}
+	pathOps := make([]internalOperation, 0)
+	for _, op := range ops {
+		if op.Path == path {
+			pathOps = append(pathOps, op)
+		}
+	}
+	if len(pathOps) < 2 {
+		return result
+	}
+	for _, p := range paramsOf(pathOps[0].Params) {
+		shared := !containsParam(result, p)
+		for _, op := range pathOps[1:] {
+			shared = shared && containsParam(paramsOf(op.Params), p)
+		}
+		if shared {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// MapErrorCode documents error responses with the type of shape under the given status code,
+// rather than under 'default'.
+// For example, MapErrorCode(ValidationError{}, 422, "Invalid parameters.") means that
+// all Operations with a ReturnErr of ValidationError{} will have a '422' response.
+func (sa *Sashay) MapErrorCode(shape interface{}, code int, description string) *Sashay {
+	resp := NewResponse(code, description, shape)
+	sa.errorCodes[resp.Field.Type] = resp
+	return sa
+}
+
+// AddErrorResponse adds an error response that is documented for all Operations,
+// like the 401 or 429 responses returned by middleware.
+// Operations which already have a response for the code keep their own response,
+// and Operations can omit it using Operation#WithoutErrorCodes.
+func (sa *Sashay) AddErrorResponse(code int, description string, shape interface{}) *Sashay {
+	sa.errorResponses = append(sa.errorResponses, NewResponse(code, description, shape))
+	return sa
+}
+
+// DefineResponse defines a reusable response with the given name.
+// It is written into components/responses,
+// and can be referenced from Operations using NewResponseRef or UseResponse.
+// The Code of resp is not used.
+// Panics if a response with the same name was already defined.
+// See https://swagger.io/docs/specification/components/
+func (sa *Sashay) DefineResponse(name string, resp Response) *Sashay {
+	for _, nr := range sa.namedResponses {
+		if nr.name == name {
+			panic(fmt.Sprintf("response %q was already defined", name))
+		}
+	}
+	sa.namedResponses = append(sa.namedResponses, namedResponse{name, resp})
+	return sa
+}
+
+// UseResponse adds a response for the status code to Operations,
+// which references the reusable response with the given name (see DefineResponse).
+// If tags are given, it is only added to Operations with any of the tags,
+// otherwise it is added to all Operations.
+// Like with AddErrorResponse, Operations which already have a response for the code keep their own response,
+// and Operations can omit it using Operation#WithoutErrorCodes.
+func (sa *Sashay) UseResponse(code int, name string, tags ...string) *Sashay {
+	sa.sharedResponses = append(sa.sharedResponses, sharedResponse{NewResponseRef(code, name), tags})
+	return sa
+}
+
+type namedResponse struct {
+	name string
+	resp Response
+}
+
+type sharedResponse struct {
+	resp Response
+	tags []string
+}
+
+// Return true if the shared response should be used for op,
+// because it has no tags or op has one of its tags.
+func (sr sharedResponse) appliesTo(op internalOperation) bool {
+	if len(sr.tags) == 0 {
+		return true
+	}
+	for _, tag := range op.Tags {
+		if containsString(sr.tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the named responses sorted by name.
+func (sa *Sashay) sortedNamedResponses() []namedResponse {
+	result := make([]namedResponse, len(sa.namedResponses))
+	copy(result, sa.namedResponses)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].name < result[j].name
+	})
+	return result
+}
+
+// Return the link for a reusable response $ref, like "#/components/responses/Unauthorized".
+// Panic if no response with the name was defined.
+func (sa *Sashay) responseRefLink(name string) string {
+	for _, nr := range sa.namedResponses {
+		if nr.name == name {
+			return fmt.Sprintf("#/components/responses/%s", name)
+		}
+	}
+	panic(fmt.Sprintf("No response named %s has been defined. Use DefineResponse to define it.", name))
+}
+
+// Return all the responses for op, including document-wide error and shared responses,
+// and using mapped error codes for 'default' responses.
+// If a mapped code is the same as the code of another of op's responses, the 'default' response is kept.
+// Responses are in the order they are declared, with 'default' last.
+// Panics if op declares more than one response for a status code.
+func (sa *Sashay) responsesFor(op internalOperation) Responses {
+	omit := make(map[string]bool, len(op.Original.OmitErrorCodes))
+	for _, code := range op.Original.OmitErrorCodes {
+		omit[strconv.Itoa(code)] = true
+	}
+	result := make(Responses, 0, len(op.Responses)+len(sa.errorResponses))
+	seen := make(map[string]bool, cap(result))
+	for _, resp := range op.Responses {
+		if seen[resp.Code] {
+			panic(fmt.Sprintf("%s %s has more than one '%s' response", op.Original.Method, op.Original.Path, resp.Code))
+		}
+		seen[resp.Code] = true
+	}
+	for _, resp := range op.Responses {
+		if mapped, ok := sa.errorCodes[resp.Field.Type]; ok && resp.Code == "default" && !resp.Field.Nil() {
+			if !seen[mapped.Code] {
+				resp.Code = mapped.Code
+				resp.Description = mapped.Description
+				seen[resp.Code] = true
+			}
+		}
+		result = append(result, resp)
+	}
+	for _, resp := range sa.errorResponses {
+		if seen[resp.Code] || omit[resp.Code] {
+			continue
+		}
+		if len(resp.ContentTypes) == 0 {
+			resp.ContentTypes = op.Original.ErrorContentTypes
+		}
+		result = append(result, resp)
+		seen[resp.Code] = true
+	}
+	for _, shared := range sa.sharedResponses {
+		if seen[shared.resp.Code] || omit[shared.resp.Code] || !shared.appliesTo(op) {
+			continue
+		}
+		result = append(result, shared.resp)
+		seen[shared.resp.Code] = true
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[j].Code == "default" && result[i].Code != "default"
+	})
+	return result
+}
+
+// AddSecurityScheme adds the security schema with the given ID, and global scope.
+// The ID is the key of the scheme in components/securitySchemes,
+// and is used by Operations to require it, like with Operation#RequireSecurity.
+// Panics if a scheme with the same ID was already added, or if the scheme is invalid.
+// See https://swagger.io/specification/#securitySchemeObject
+func (sa *Sashay) AddSecurityScheme(id string, scheme SecurityScheme) *Sashay {
+	if _, ok := sa.security(id); ok {
+		panic(fmt.Sprintf("security scheme %q was already added", id))
+	}
+	scheme.validate(id)
+	sa.securities = append(sa.securities, swaggerSecurity{id, scheme})
 	return sa
 }
 
-type swaggerTag struct {
-	name, desc string
+// AddHTTPSecurity adds type:http security schema with the given ID, HTTP authorization scheme, and description,
+// and global scope.
+// scheme can be any HTTP authorization scheme, like "digest", or a custom one, like "hmac-sha256".
+// See https://www.iana.org/assignments/http-authschemes/http-authschemes.xhtml
+func (sa *Sashay) AddHTTPSecurity(id, scheme, description string) *Sashay {
+	return sa.AddSecurityScheme(id, SecurityScheme{Type: "http", Scheme: scheme, Description: description})
 }
 
-// AddBasicAuthSecurity adds type:http scheme:basic security schema and global scope.
+// AddMutualTLSSecurity adds type:mutualTLS security schema with the given ID and description, and global scope.
+// mutualTLS is only available in OpenAPI 3.1, so BuildYAML panics unless OpenAPIVersion is 3.1.
+func (sa *Sashay) AddMutualTLSSecurity(id, description string) *Sashay {
+	return sa.AddSecurityScheme(id, SecurityScheme{Type: "mutualTLS", Description: description})
+}
+
+// AddBasicAuthSecurity adds type:http scheme:basic security schema with the ID "basicAuth", and global scope.
 // See https://swagger.io/specification/#securitySchemeObject
 // https://swagger.io/docs/specification/authentication/basic-authentication/
 func (sa *Sashay) AddBasicAuthSecurity() *Sashay {
-	sec := swaggerSecurity{"id": "basicAuth", "type": "http", "scheme": "basic"}
-	sa.securities = append(sa.securities, sec)
-	return sa
+	return sa.AddSecurityScheme("basicAuth", SecurityScheme{Type: "http", Scheme: "basic"})
 }
 
-// AddJWTSecurity adds type:http scheme:bearer security schema and global scope.
+// AddJWTSecurity adds type:http scheme:bearer security schema with the ID "bearerAuth", and global scope.
 // See https://swagger.io/specification/#securitySchemeObject
 // https://swagger.io/docs/specification/authentication/bearer-authentication/
 func (sa *Sashay) AddJWTSecurity() *Sashay {
-	sec := swaggerSecurity{"id": "bearerAuth", "type": "http", "scheme": "bearer", "bearerFormat": "JWT"}
-	sa.securities = append(sa.securities, sec)
-	return sa
+	return sa.AddSecurityScheme("bearerAuth", SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"})
 }
 
-// AddAPIKeySecurity adds type:apiKey security schema and global scope.
+// AddAPIKeySecurity adds type:apiKey security schema with the ID "apiKeyAuth", and global scope.
+// Use AddSecurityScheme to add more than one API key.
 // See https://swagger.io/specification/#securitySchemeObject
 // https://swagger.io/docs/specification/authentication/api-keys/
 func (sa *Sashay) AddAPIKeySecurity(in, name string) *Sashay {
-	sec := swaggerSecurity{"id": "apiKeyAuth", "type": "apiKey", "in": in, "name": name}
-	sa.securities = append(sa.securities, sec)
-	return sa
+	return sa.AddSecurityScheme("apiKeyAuth", SecurityScheme{Type: "apiKey", In: in, Name: name})
+}
+
+// AddOAuth2Security adds type:oauth2 security schema with the ID "oauth2", with the given flows, and global scope.
+// Operations can require specific scopes with Operation#RequireScopes("oauth2", ...).
+// Panics if a flow is missing a URL it needs, or if there is more than one flow of the same type.
+// See https://swagger.io/specification/#securitySchemeObject
+// https://swagger.io/docs/specification/authentication/oauth2/
+func (sa *Sashay) AddOAuth2Security(flows ...OAuthFlow) *Sashay {
+	return sa.AddSecurityScheme("oauth2", SecurityScheme{Type: "oauth2", Flows: flows})
 }
 
-type swaggerSecurity ObjectFields
+// AddOpenIDConnectSecurity adds type:openIdConnect security schema with the ID "openIdConnect", and global scope.
+// discoveryURL is the OpenID Connect discovery URL, like https://example.com/.well-known/openid-configuration.
+// See https://swagger.io/specification/#securitySchemeObject
+// https://swagger.io/docs/specification/authentication/openid-connect-discovery/
+func (sa *Sashay) AddOpenIDConnectSecurity(discoveryURL string) *Sashay {
+	return sa.AddSecurityScheme("openIdConnect", SecurityScheme{Type: "openIdConnect", OpenIDConnectURL: discoveryURL})
+}
 
-func (ss swaggerSecurity) ID() string {
-	return ss["id"]
+// DefineSchemaExample sets a populated value as the example for the schema of its type
+// in components/schemas, serialized with encoding/json.
+// For example, DefineSchemaExample(User{ID: 5, Name: "Spot"}) means the User schema
+// would have an example of {"id":5,"name":"Spot"}.
+func (sa *Sashay) DefineSchemaExample(example interface{}) *Sashay {
+	sa.schemaExamples[NewField(example).Type] = example
+	return sa
 }
 
-func (ss swaggerSecurity) Fields() ObjectFields {
-	of := make(ObjectFields)
-	for k, v := range ss {
-		if k != "id" {
-			of[k] = v
-		}
-	}
-	return of
+// DefineSchemaExtensions sets the vendor extensions for the schema of the type of value
+// in components/schemas, like DefineSchemaExtensions(User{}, Extensions{"x-go-type": "models.User"}).
+func (sa *Sashay) DefineSchemaExtensions(value interface{}, ext Extensions) *Sashay {
+	sa.schemaExtensions[NewField(value).Type] = ext
+	return sa
 }
 
 // DefineDataType defines the DataTyper to use for values with the same type as i.
@@ -218,34 +710,194 @@ func (sa *Sashay) defineDataTypeForKind(kind reflect.Kind, dt dataTypeDef) {
 	}
 }
 
+// WriteYAML writes the YAML Swagger string for the receiver into buf.
+// Returns an error if the receiver is not valid (see Validate).
 func (sa *Sashay) WriteYAML(buf io.Writer) error {
-	bb := &baseBuilder{buf, sa}
+	if err := sa.Validate(); err != nil {
+		return err
+	}
+	bb := &baseBuilder{buf, sa, sa.componentRequestContentTypes()}
 	db := docBuilder{bb}
 	db.writeInfo()
 	db.writeTags()
 	db.writeServers()
+	db.writeExternalDocs()
 	pb := pathBuilder{bb}
 	pb.writePaths()
+	pb.writeWebhooks()
 	cp := componentsBuilder{bb}
 	cp.writeComponents()
+	db.writeExtensions()
 	return nil
 }
 
 // BuildYAML returns the YAML Swagger string for the receiver.
+// Panics if the receiver is not valid (see Validate).
 func (sa *Sashay) BuildYAML() string {
 	buf := bytes.NewBuffer(nil)
-	sa.WriteYAML(buf)
+	if err := sa.WriteYAML(buf); err != nil {
+		panic(err)
+	}
 	return buf.String()
 }
 
+// Validate returns an error if the receiver cannot be written into a valid document,
+// like if two Operations have the same operationId, or a Link targets an Operation that was not added.
+// It is called by WriteYAML.
+func (sa *Sashay) Validate() error {
+	seen := make(map[OperationID]internalOperation, len(sa.operations))
+	for _, op := range sa.operations {
+		id := sa.operationID(op)
+		if other, ok := seen[id]; ok {
+			return fmt.Errorf("operations %s %s and %s %s have the same operationId %q, "+
+				"use Operation.OperationID to give one of them a different ID",
+				other.Original.Method, other.Original.Path, op.Original.Method, op.Original.Path, id)
+		}
+		seen[id] = op
+	}
+	for _, resp := range sa.allResponses() {
+		for _, link := range sa.linksOf(resp) {
+			if _, err := sa.linkTarget(link); err != nil {
+				return err
+			}
+		}
+	}
+	for _, op := range sa.outboundOperations() {
+		if op.Original.OperationID == "" {
+			continue
+		}
+		id := OperationID(op.Original.OperationID)
+		if _, ok := seen[id]; ok {
+			return fmt.Errorf("operationId %q is used more than once, including by a webhook or callback", id)
+		}
+		seen[id] = op
+	}
+	return nil
+}
+
+// Return every response written into the document.
+func (sa *Sashay) allResponses() Responses {
+	result := make(Responses, 0)
+	for _, op := range sa.operations {
+		result = append(result, sa.responsesFor(op)...)
+	}
+	for _, op := range sa.outboundOperations() {
+		result = append(result, op.Responses...)
+	}
+	for _, nr := range sa.namedResponses {
+		result = append(result, nr.resp)
+	}
+	return result
+}
+
+// Return the links of resp to write into the document.
+// Links to Operations that SelectMap removed (like WithoutDeprecated) are dropped.
+func (sa *Sashay) linksOf(resp Response) []Link {
+	result := make([]Link, 0, len(resp.Links))
+	for _, link := range resp.Links {
+		if !sa.linkTargetRemoved(link) {
+			result = append(result, link)
+		}
+	}
+	return result
+}
+
+// Return true if link targets an Operation SelectMap removed, and no Operation that was kept.
+func (sa *Sashay) linkTargetRemoved(link Link) bool {
+	if _, ok := sa.findLinkTarget(link); ok {
+		return false
+	}
+	for _, op := range sa.removedOperations {
+		if sa.linkTargets(link, op) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return true if link targets op, as it is or as it was before SelectMap modified it.
+func (sa *Sashay) linkTargets(link Link, op internalOperation) bool {
+	return sa.linkTargetsOperation(link, op.Original) || sa.linkTargetsOperation(link, op.Source)
+}
+
+func (sa *Sashay) linkTargetsOperation(link Link, op Operation) bool {
+	if link.Operation != nil {
+		return NewMethod(op.Method) == NewMethod(link.Operation.Method) && NewPath(op.Path) == NewPath(link.Operation.Path)
+	}
+	return sa.operationID(internalOperation{Original: op}) == OperationID(link.OperationID)
+}
+
+// Return the added Operation the link targets, and true,
+// or false if it targets none of them.
+func (sa *Sashay) findLinkTarget(link Link) (internalOperation, bool) {
+	for _, op := range sa.operations {
+		if sa.linkTargets(link, op) {
+			return op, true
+		}
+	}
+	return internalOperation{}, false
+}
+
+// Return the Operation the link targets,
+// or an error if it is not added to the receiver, or does not have the parameters the link maps,
+// or the link's name is not valid.
+// Only Operations added with Add can be targeted; webhooks and callbacks are not part of the API's paths.
+func (sa *Sashay) linkTarget(link Link) (internalOperation, error) {
+	if !componentKeyPattern.MatchString(link.Name) {
+		return internalOperation{}, fmt.Errorf("link name %q must not be empty, "+
+			"and can only use letters, digits, '.', '-', and '_'", link.Name)
+	}
+	if op, ok := sa.findLinkTarget(link); ok {
+		params := paramsOf(op.Params)
+		for _, spp := range sa.sharedPathParams {
+			if spp.appliesTo(op.Path) {
+				params = append(params, spp.params...)
+			}
+		}
+		for _, name := range link.sortedParameters() {
+			if !containsParamNamed(params, name) {
+				return op, fmt.Errorf("link %q maps parameter %q, which %s %s does not have",
+					link.Name, name, op.Original.Method, op.Original.Path)
+			}
+		}
+		return op, nil
+	}
+	if link.Operation != nil {
+		return internalOperation{}, fmt.Errorf("link %q targets %s %s, which is not added to the Sashay",
+			link.Name, link.Operation.Method, link.Operation.Path)
+	}
+	return internalOperation{}, fmt.Errorf("link %q targets operationId %q, which is not added to the Sashay",
+		link.Name, link.OperationID)
+}
+
+// The pattern for names of components, and other map keys like link names.
+// See https://swagger.io/specification/#componentsObject
+var componentKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9.\-_]+$`)
+
+// Return the operationId for op, which is its OperationID if set,
+// otherwise determined by the OperationIDer.
+func (sa *Sashay) operationID(op internalOperation) OperationID {
+	if op.Original.OperationID != "" {
+		return OperationID(op.Original.OperationID)
+	}
+	if sa.OperationIDer == nil {
+		return NewOperationID(op.Original)
+	}
+	return sa.OperationIDer(op.Original)
+}
+
 // WriteYAMLFile writes the YAML Swagger string to the file at filename.
 // File-writing behavior works like ioutil.WriteFile.
+// The receiver is validated before the file is opened, so an invalid receiver leaves the file untouched.
 func (sa *Sashay) WriteYAMLFile(filename string) error {
+	if err := sa.Validate(); err != nil {
+		return err
+	}
 	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-	defer f.Close()
 	if err != nil {
 		return err
 	}
+	defer f.Close()
 	return sa.WriteYAML(f)
 }
 
@@ -310,7 +962,7 @@ func enumerateStructFields(field Field) Fields {
 
 func enumerateStructFieldsInner(fieldType reflect.Type, origStructValue reflect.Value) Fields {
 	structValue := origStructValue
-	if structValue.Kind() == reflect.Ptr {
+	if structValue.Kind() == reflect.Ptr && structValue.IsNil() {
 		structValue = reflect.Zero(fieldType)
 	}
 	structValue = reflect.Indirect(structValue)
@@ -368,6 +1020,11 @@ func schemaRefLink(f Field) string {
 	return fmt.Sprintf("#/components/schemas/%s", f.Type.Name())
 }
 
+// Return the link for a request body $ref field, like "#/components/requestBodies/UserParams".
+func requestBodyRefLink(f Field) string {
+	return fmt.Sprintf("#/components/requestBodies/%s", f.Type.Name())
+}
+
 // SelectMap is used to process a source Sashay registry into an alternative version,
 // like for removing Operations/endpoints matching a certain criteria.
 // A new registry is returned with all the values copied from source; the source registry is not modified.
@@ -376,9 +1033,19 @@ func schemaRefLink(f Field) string {
 // and returns nil if the Operation should be excluded,
 // or a pointer to the Operation if it should remain in the registry.
 // Note that fn can modify the input Operation and those changes will be reflected into the resulting Sashay instance.
+// Response links to excluded Operations are not written into the document,
+// and links to modified Operations target them as they were before fn modified them.
 func SelectMap(source *Sashay, fn func(op Operation) *Operation) *Sashay {
 	dest := Sashay{
 		DefaultContentType: source.DefaultContentType,
+		ParamRequirer:      source.ParamRequirer,
+		OperationIDer:      source.OperationIDer,
+		RequestBodyRefs:    source.RequestBodyRefs,
+		ExamplesFromValues: source.ExamplesFromValues,
+		ShareCommonParams:  source.ShareCommonParams,
+		OpenAPIVersion:     source.OpenAPIVersion,
+		Extensions:         source.Extensions,
+		InfoExtensions:     source.InfoExtensions,
 		title:              source.title,
 		desc:               source.desc,
 		version:            source.version,
@@ -388,26 +1055,66 @@ func SelectMap(source *Sashay, fn func(op Operation) *Operation) *Sashay {
 		contactEmail:       source.contactEmail,
 		licenseName:        source.licenseName,
 		licenseURL:         source.licenseURL,
+		externalDocs:       source.externalDocs,
 	}
-	dest.servers = make([]swaggerServer, len(source.servers))
+	dest.servers = make([]Server, len(source.servers))
 	copy(dest.servers, source.servers)
 	dest.securities = make([]swaggerSecurity, len(source.securities))
 	copy(dest.securities, source.securities)
-	dest.tags = make([]swaggerTag, len(source.tags))
+	dest.tags = make([]Tag, len(source.tags))
 	copy(dest.tags, source.tags)
+	dest.errorResponses = make(Responses, len(source.errorResponses))
+	copy(dest.errorResponses, source.errorResponses)
+	dest.schemaExamples = make(map[reflect.Type]interface{}, len(source.schemaExamples))
+	for k, v := range source.schemaExamples {
+		dest.schemaExamples[k] = v
+	}
+	dest.schemaExtensions = make(map[reflect.Type]Extensions, len(source.schemaExtensions))
+	for k, v := range source.schemaExtensions {
+		dest.schemaExtensions[k] = v
+	}
+	dest.webhooks = make([]webhook, len(source.webhooks))
+	copy(dest.webhooks, source.webhooks)
+	dest.sharedPathParams = make([]sharedPathParams, len(source.sharedPathParams))
+	copy(dest.sharedPathParams, source.sharedPathParams)
+	dest.namedResponses = make([]namedResponse, len(source.namedResponses))
+	copy(dest.namedResponses, source.namedResponses)
+	dest.sharedResponses = make([]sharedResponse, len(source.sharedResponses))
+	copy(dest.sharedResponses, source.sharedResponses)
+	dest.errorCodes = make(map[reflect.Type]Response, len(source.errorCodes))
+	for k, v := range source.errorCodes {
+		dest.errorCodes[k] = v
+	}
 	dest.dataTypesForTypes = make(map[reflect.Type]dataTypeDef, len(source.dataTypesForTypes))
 	for k, v := range source.dataTypesForTypes {
 		dest.dataTypesForTypes[k] = v
 	}
+	dest.removedOperations = make([]internalOperation, len(source.removedOperations))
+	copy(dest.removedOperations, source.removedOperations)
 	dest.operations = make([]internalOperation, 0, len(source.operations))
 	for _, op := range source.operations {
 		if newOp := fn(op.Original); newOp != nil {
 			dest.Add(*newOp)
+			dest.operations[len(dest.operations)-1].Source = op.Source
+		} else {
+			dest.removedOperations = append(dest.removedOperations, op)
 		}
 	}
 	return &dest
 }
 
+// WithoutDeprecated returns a new registry with the values of source,
+// and all of its Operations that are not deprecated.
+// It is useful for publishing a document without endpoints that are being removed.
+func WithoutDeprecated(source *Sashay) *Sashay {
+	return SelectMap(source, func(op Operation) *Operation {
+		if op.Deprecated {
+			return nil
+		}
+		return &op
+	})
+}
+
 const fileBugBasePanicMsg = "This should not occur in the wild. " +
 	"Please file a bug at https://github.com/rgalanakis/sashay/issues/new " +
 	"with as much reproduction information as possible, " +
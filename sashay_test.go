@@ -2,11 +2,13 @@ package sashay_test
 
 import (
 	"bytes"
+	"fmt"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/rgalanakis/sashay"
 	"io/ioutil"
 	"math/rand"
+	"mime/multipart"
 	"os"
 	"strings"
 	"testing"
@@ -25,6 +27,16 @@ type User struct {
 	} `json:"result"`
 }
 
+type Address struct {
+	City string `json:"city"`
+}
+
+type CreateUserParams struct {
+	ID      int     `path:"id"`
+	Name    string  `json:"name"`
+	Address Address `json:"address"`
+}
+
 type ErrorModel struct {
 	Error struct {
 		Message string `json:"message"`
@@ -116,6 +128,216 @@ security:
 `))
 	})
 
+	Describe("operation security", func() {
+		BeforeEach(func() {
+			sw.AddBasicAuthSecurity()
+			sw.AddJWTSecurity()
+			sw.AddAPIKeySecurity("header", "X-MY-APIKEY")
+		})
+
+		It("uses the global security when not set", func() {
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, nil))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`          description: error response
+components:
+`))
+		})
+
+		It("can mark an operation as public", func() {
+			sw.Add(sashay.NewOperation("GET", "/health", "", nil, nil, nil).Public())
+			Expect(sw.BuildYAML()).To(ContainSubstring(`          description: error response
+      security: []
+`))
+		})
+
+		It("can require any of a subset of schemes", func() {
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, nil).RequireSecurity("bearerAuth", "apiKeyAuth"))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`      security:
+        - bearerAuth: []
+        - apiKeyAuth: []
+`))
+		})
+
+		It("can require schemes to be used together", func() {
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, nil).
+				RequireAllSecurity("bearerAuth", "apiKeyAuth").
+				RequireAllSecurity("basicAuth"))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`      security:
+        - apiKeyAuth: []
+          bearerAuth: []
+        - basicAuth: []
+`))
+		})
+
+		It("can make security optional", func() {
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, nil).
+				WithSecurity(sashay.SecurityRequirement{"bearerAuth": {}}, sashay.SecurityRequirement{}))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`      security:
+        - bearerAuth: []
+        - {}
+`))
+		})
+
+		It("panics if a scheme is not defined", func() {
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, nil).RequireSecurity("oauth"))
+			Expect(func() { sw.BuildYAML() }).To(PanicWith(ContainSubstring(`security scheme "oauth" is not defined`)))
+		})
+	})
+
+	Describe("custom security schemes", func() {
+		It("can add multiple schemes of the same type with custom IDs", func() {
+			sw.AddSecurityScheme("serverKey", sashay.SecurityScheme{
+				Type:        "apiKey",
+				Description: "Key for server-to-server calls.",
+				In:          "header",
+				Name:        "X-Server-Key",
+			})
+			sw.AddSecurityScheme("webhookKey", sashay.SecurityScheme{Type: "apiKey", In: "query", Name: "key"})
+			sw.Add(sashay.NewOperation("POST", "/webhooks", "", nil, nil, nil).RequireSecurity("webhookKey"))
+			result := sw.BuildYAML()
+			Expect(result).To(ContainSubstring(`  securitySchemes:
+    serverKey:
+      type: apiKey
+      description: Key for server-to-server calls.
+      in: header
+      name: X-Server-Key
+    webhookKey:
+      type: apiKey
+      in: query
+      name: key
+security:
+  - serverKey: []
+  - webhookKey: []
+`))
+			Expect(result).To(ContainSubstring(`      security:
+        - webhookKey: []
+`))
+		})
+
+		It("can add arbitrary http schemes", func() {
+			sw.AddHTTPSecurity("hmacAuth", "hmac-sha256", "Requests are signed with the partner secret.")
+			sw.Add(sashay.NewOperation("POST", "/partners/orders", "", nil, nil, nil).RequireSecurity("hmacAuth"))
+			result := sw.BuildYAML()
+			Expect(result).To(ContainSubstring(`    hmacAuth:
+      type: http
+      description: Requests are signed with the partner secret.
+      scheme: hmac-sha256
+`))
+			Expect(result).To(ContainSubstring(`      security:
+        - hmacAuth: []
+`))
+		})
+
+		It("can add mutualTLS schemes to OpenAPI 3.1 documents", func() {
+			sw.OpenAPIVersion = sashay.OpenAPIVersion31
+			sw.AddMutualTLSSecurity("meshTLS", "Service mesh client certificates.")
+			sw.Add(sashay.NewOperation("GET", "/internal/stats", "", nil, nil, nil).RequireSecurity("meshTLS"))
+			result := sw.BuildYAML()
+			Expect(result).To(HavePrefix("openapi: 3.1.0\n"))
+			Expect(result).To(ContainSubstring(`    meshTLS:
+      type: mutualTLS
+      description: Service mesh client certificates.
+`))
+			Expect(result).To(ContainSubstring(`      security:
+        - meshTLS: []
+`))
+		})
+
+		It("panics for mutualTLS schemes in OpenAPI 3.0 documents", func() {
+			sw.AddMutualTLSSecurity("meshTLS", "")
+			Expect(func() { sw.BuildYAML() }).To(PanicWith(`security scheme "meshTLS" of type mutualTLS requires OpenAPIVersion 3.1`))
+		})
+
+		It("panics for duplicate IDs", func() {
+			sw.AddAPIKeySecurity("header", "X-Key")
+			Expect(func() {
+				sw.AddSecurityScheme("apiKeyAuth", sashay.SecurityScheme{Type: "apiKey", In: "query", Name: "key"})
+			}).To(PanicWith(`security scheme "apiKeyAuth" was already added`))
+		})
+
+		It("panics for invalid schemes", func() {
+			Expect(func() {
+				sw.AddSecurityScheme("digest", sashay.SecurityScheme{Type: "http"})
+			}).To(PanicWith(`security scheme "digest" of type http requires a Scheme`))
+			Expect(func() {
+				sw.AddSecurityScheme("key", sashay.SecurityScheme{Type: "apiKey", In: "body", Name: "key"})
+			}).To(PanicWith(`security scheme "key" has invalid In "body", must be query, header, or cookie`))
+			Expect(func() {
+				sw.AddSecurityScheme("saml", sashay.SecurityScheme{Type: "saml"})
+			}).To(PanicWith(`security scheme "saml" has invalid type "saml"`))
+		})
+	})
+
+	Describe("OAuth2 and OpenID Connect security", func() {
+		It("writes flows and scopes", func() {
+			sw.AddOAuth2Security(
+				sashay.OAuthFlow{
+					Type:             sashay.OAuthFlowAuthorizationCode,
+					AuthorizationURL: "https://example.com/oauth/authorize",
+					TokenURL:         "https://example.com/oauth/token",
+					RefreshURL:       "https://example.com/oauth/refresh",
+					Scopes:           map[string]string{"pets:write": "Modify pets.", "pets:read": "Read pets."},
+				},
+				sashay.OAuthFlow{
+					Type:     sashay.OAuthFlowClientCredentials,
+					TokenURL: "https://example.com/oauth/token",
+				},
+			)
+			sw.AddOpenIDConnectSecurity("https://example.com/.well-known/openid-configuration")
+			Expect(sw.BuildYAML()).To(ContainSubstring(`  securitySchemes:
+    oauth2:
+      type: oauth2
+      flows:
+        authorizationCode:
+          authorizationUrl: https://example.com/oauth/authorize
+          tokenUrl: https://example.com/oauth/token
+          refreshUrl: https://example.com/oauth/refresh
+          scopes:
+            pets:read: Read pets.
+            pets:write: Modify pets.
+        clientCredentials:
+          tokenUrl: https://example.com/oauth/token
+          scopes: {}
+    openIdConnect:
+      type: openIdConnect
+      openIdConnectUrl: https://example.com/.well-known/openid-configuration
+security:
+  - oauth2: []
+  - openIdConnect: []
+`))
+		})
+
+		It("writes scopes required by operations", func() {
+			sw.AddOAuth2Security(sashay.OAuthFlow{
+				Type:             sashay.OAuthFlowImplicit,
+				AuthorizationURL: "https://example.com/oauth/authorize",
+				Scopes:           map[string]string{"pets:write": "Modify pets.", "pets:read": "Read pets."},
+			})
+			sw.AddOpenIDConnectSecurity("https://example.com/.well-known/openid-configuration")
+			sw.Add(sashay.NewOperation("DELETE", "/pets", "", nil, nil, nil).
+				RequireScopes("oauth2", "pets:read", "pets:write").
+				RequireScopes("openIdConnect", "admin"))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`      security:
+        - oauth2: ["pets:read", "pets:write"]
+        - openIdConnect: ["admin"]
+`))
+		})
+
+		It("panics for undeclared OAuth2 scopes", func() {
+			sw.AddOAuth2Security(sashay.OAuthFlow{Type: sashay.OAuthFlowPassword, TokenURL: "https://example.com/token"})
+			sw.Add(sashay.NewOperation("DELETE", "/pets", "", nil, nil, nil).RequireScopes("oauth2", "pets:write"))
+			Expect(func() { sw.BuildYAML() }).To(PanicWith(`security scheme "oauth2" does not define scope "pets:write"`))
+		})
+
+		It("panics for invalid flows", func() {
+			Expect(func() {
+				sw.AddOAuth2Security(sashay.OAuthFlow{Type: sashay.OAuthFlowAuthorizationCode, TokenURL: "https://example.com/token"})
+			}).To(PanicWith("OAuth2 authorizationCode flow requires an AuthorizationURL"))
+			Expect(func() {
+				sw.AddOAuth2Security(sashay.OAuthFlow{Type: "device"})
+			}).To(PanicWith(`invalid OAuth2 flow type "device"`))
+		})
+	})
+
 	It("generates paths for routes with no parameters", func() {
 		sw.Add(sashay.NewOperation(
 			"GET",
@@ -299,6 +521,320 @@ security:
 `))
 	})
 
+	It("can use per-operation and per-response content types", func() {
+		sw.Add(sashay.NewOperation(
+			"GET",
+			"/users/export",
+			"",
+			nil,
+			sashay.Responses{
+				sashay.NewResponse(200, "ok", []User{}),
+				sashay.NewResponse(206, "partial", "").WithContentTypes("text/plain"),
+			},
+			ErrorModel{},
+		).
+			WithResponseContentTypes("application/json", "text/csv").
+			WithErrorContentTypes("application/problem+json"))
+		Expect(sw.BuildYAML()).To(ContainSubstring(`      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/User'
+            text/csv:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/User'
+        '206':
+          description: partial
+          content:
+            text/plain:
+              schema:
+                type: string
+        'default':
+          description: error response
+          content:
+            application/problem+json:
+              schema:
+                $ref: '#/components/schemas/ErrorModel'
+`))
+	})
+
+	It("can use multiple media types with their own schemas", func() {
+		type UserXML struct {
+			Name string `json:"name"`
+		}
+		sw.Add(sashay.NewOperation(
+			"PUT",
+			"/users",
+			"",
+			CreateUserParams{},
+			sashay.NewResponse(200, "ok", []User{}).WithMediaTypes(
+				sashay.NewMediaType("application/json", nil),
+				sashay.NewMediaType("text/csv", ""),
+				sashay.NewMediaType("application/x-ndjson", User{}),
+			),
+			nil,
+		).WithRequestMediaTypes(
+			sashay.NewMediaType("application/json", nil),
+			sashay.NewMediaType("application/xml", UserXML{}),
+		))
+		Expect(sw.BuildYAML()).To(ContainSubstring(`      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string
+                address:
+                  type: object
+                  properties:
+                    city:
+                      type: string
+          application/xml:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/User'
+            text/csv:
+              schema:
+                type: string
+            application/x-ndjson:
+              schema:
+                $ref: '#/components/schemas/User'
+`))
+	})
+
+	It("writes response headers", func() {
+		type RateLimitHeaders struct {
+			RetryAfter int `header:"Retry-After" description:"Seconds to wait." required:"true"`
+		}
+		sw.Add(sashay.NewOperation(
+			"POST",
+			"/users",
+			"",
+			nil,
+			sashay.NewResponse(201, "created", User{}).WithHeaders(struct {
+				Location string `header:"Location" description:"URL of the new user."`
+				ETag     string `header:"ETag"`
+			}{}),
+			sashay.NewResponse(429, "too many requests", nil).WithHeaders(RateLimitHeaders{}),
+		))
+		Expect(sw.BuildYAML()).To(ContainSubstring(`      responses:
+        '201':
+          description: created
+          headers:
+            Location:
+              description: URL of the new user.
+              schema:
+                type: string
+            ETag:
+              schema:
+                type: string
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+        '429':
+          description: too many requests
+          headers:
+            Retry-After:
+              description: Seconds to wait.
+              required: true
+              schema:
+                type: integer
+                format: int64
+`))
+	})
+
+	Describe("error responses", func() {
+		type ValidationError struct {
+			Fields []string `json:"fields"`
+		}
+
+		BeforeEach(func() {
+			sw.MapErrorCode(ValidationError{}, 422, "invalid parameters")
+			sw.AddErrorResponse(429, "too many requests", ErrorModel{})
+			sw.AddErrorResponse(401, "unauthorized", ErrorModel{})
+		})
+
+		It("maps error shapes to status codes and adds document-wide error responses", func() {
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, ValidationError{}))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`      operationId: getUsers
+      responses:
+        '204':
+          description: The operation completed successfully.
+        '422':
+          description: invalid parameters
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ValidationError'
+        '429':
+          description: too many requests
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorModel'
+        '401':
+          description: unauthorized
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorModel'
+components:
+`))
+		})
+
+		It("keeps the default response if a mapped error code is one of the operation's own", func() {
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, ValidationError{}).
+				AddErrorResponses(sashay.NewResponse(422, "bad filter", nil)))
+			result := sw.BuildYAML()
+			Expect(result).To(ContainSubstring(`        '204':
+          description: The operation completed successfully.
+        '422':
+          description: bad filter
+        '429':
+`))
+			Expect(result).To(ContainSubstring(`        'default':
+          description: error response
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ValidationError'
+components:
+`))
+		})
+
+		It("panics if an operation has more than one response for a code", func() {
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, nil).
+				AddErrorResponses(sashay.NewResponse(404, "not found", nil), sashay.NewResponse(404, "gone", nil)))
+			Expect(func() { sw.BuildYAML() }).To(PanicWith(`GET /users has more than one '404' response`))
+		})
+
+		It("can add and remove error responses per operation", func() {
+			sw.Add(sashay.NewOperation("GET", "/users/:id", "", nil, nil, ErrorModel{}).
+				AddErrorResponses(
+					sashay.NewResponse(404, "not found", ErrorModel{}),
+					sashay.NewResponse(429, "slow down", nil),
+				).
+				WithoutErrorCodes(401))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`      operationId: getUsersId
+      responses:
+        '204':
+          description: The operation completed successfully.
+        '404':
+          description: not found
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorModel'
+        '429':
+          description: slow down
+        'default':
+          description: error response
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorModel'
+components:
+`))
+		})
+	})
+
+	Describe("reusable responses", func() {
+		BeforeEach(func() {
+			sw.DefineResponse("Unauthorized", sashay.NewResponse(401, "Not authenticated.", ErrorModel{}))
+			sw.DefineResponse("Forbidden", sashay.NewResponse(403, "Not allowed.", nil))
+			sw.UseResponse(401, "Unauthorized")
+			sw.UseResponse(403, "Forbidden", "admin")
+		})
+
+		It("writes reusable responses into components and references them", func() {
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, nil).
+				AddErrorResponses(sashay.NewResponseRef(404, "Forbidden")))
+			sw.Add(sashay.NewOperation("DELETE", "/users", "", nil, nil, nil).AddTags("admin"))
+			sw.Add(sashay.NewOperation("POST", "/login", "", nil, nil, nil).WithoutErrorCodes(401))
+			yaml := sw.BuildYAML()
+			Expect(yaml).To(ContainSubstring(`  /login:
+    post:
+      operationId: postLogin
+      responses:
+        '204':
+          description: The operation completed successfully.
+        'default':
+          description: error response
+  /users:
+    get:
+      operationId: getUsers
+      responses:
+        '204':
+          description: The operation completed successfully.
+        '404':
+          $ref: '#/components/responses/Forbidden'
+        '401':
+          $ref: '#/components/responses/Unauthorized'
+        'default':
+          description: error response
+    delete:
+      tags: ["admin"]
+      operationId: deleteUsers
+      responses:
+        '204':
+          description: The operation completed successfully.
+        '401':
+          $ref: '#/components/responses/Unauthorized'
+        '403':
+          $ref: '#/components/responses/Forbidden'
+        'default':
+          description: error response
+components:
+  schemas:
+    ErrorModel:
+`))
+			Expect(yaml).To(HaveSuffix(`  responses:
+    Forbidden:
+      description: Not allowed.
+    Unauthorized:
+      description: Not authenticated.
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/ErrorModel'
+`))
+		})
+
+		It("panics if a referenced response is not defined", func() {
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, sashay.NewResponseRef(404, "NotFound")))
+			Expect(func() {
+				sw.BuildYAML()
+			}).To(Panic())
+		})
+
+		It("panics if a response with the same name was already defined", func() {
+			sw.DefineResponse("NotFound", sashay.NewResponse(404, "Not found.", nil))
+			Expect(func() {
+				sw.DefineResponse("NotFound", sashay.NewResponse(404, "Does not exist.", nil))
+			}).To(PanicWith(`response "NotFound" was already defined`))
+		})
+	})
+
 	It("interprets string responses as text/plain", func() {
 		sw.Add(sashay.NewOperation(
 			"GET",
@@ -420,27 +956,188 @@ security:
 `))
 	})
 
-	It("can use an alternative global content type", func() {
-		sw.DefaultContentType = "application/myapp+json+v1"
+	It("generates cookie parameters", func() {
 		sw.Add(sashay.NewOperation(
-			"POST",
-			"/users/:id",
-			"Update the user",
+			"GET",
+			"/users",
+			"Returns a list of users.",
 			struct {
-				ID   string `path:"id"`
-				Name string `json:"name"`
+				Session string `cookie:"session_id" description:"Session cookie."`
+				Pretty  bool   `query:"pretty"`
 			}{},
-			User{},
-			ErrorModel{},
+			nil,
+			nil,
 		))
-		Expect(sw.BuildYAML()).To(ContainSubstring(`paths:
-  /users/{id}:
-    post:
-      operationId: postUsersId
-      summary: Update the user
-      parameters:
-        - name: id
-          in: path
+		Expect(sw.BuildYAML()).To(ContainSubstring(`      parameters:
+        - name: session_id
+          in: cookie
+          description: Session cookie.
+          schema:
+            type: string
+        - name: pretty
+          in: query
+          schema:
+            type: boolean
+`))
+	})
+
+	Describe("required parameters", func() {
+		params := struct {
+			ID       int    `path:"id"`
+			Tagged   string `query:"tagged" required:"true"`
+			Optional string `query:"optional" required:"false"`
+			Valid    string `header:"X-Valid" validate:"min=1,required"`
+			Plain    string `cookie:"plain"`
+			Pointer  *int   `query:"pointer"`
+		}{}
+
+		It("are driven by struct tags", func() {
+			sw.Add(sashay.NewOperation("GET", "/users/:id", "", params, nil, nil))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+            format: int64
+        - name: tagged
+          in: query
+          required: true
+          schema:
+            type: string
+        - name: optional
+          in: query
+          schema:
+            type: string
+        - name: X-Valid
+          in: header
+          required: true
+          schema:
+            type: string
+        - name: plain
+          in: cookie
+          schema:
+            type: string
+        - name: pointer
+          in: query
+          schema:
+            type: integer
+            format: int64
+`))
+		})
+
+		It("can be driven by a document-wide policy", func() {
+			sw.ParamRequirer = sashay.NonPointerParamsRequired
+			sw.Add(sashay.NewOperation("GET", "/users/:id", "", params, nil, nil))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`        - name: optional
+          in: query
+          schema:
+            type: string
+        - name: X-Valid
+          in: header
+          required: true
+          schema:
+            type: string
+        - name: plain
+          in: cookie
+          required: true
+          schema:
+            type: string
+        - name: pointer
+          in: query
+          schema:
+            type: integer
+            format: int64
+`))
+		})
+	})
+
+	It("writes style and explode for parameters", func() {
+		type Filter struct {
+			Name string `json:"name"`
+			Age  int    `json:"age"`
+		}
+		sw.Add(sashay.NewOperation(
+			"GET",
+			"/users",
+			"",
+			struct {
+				IDs    []string `query:"ids" style:"form" explode:"false"`
+				Colors []string `query:"colors" style:"pipeDelimited"`
+				Filter Filter   `query:"filter"`
+			}{},
+			nil,
+			nil,
+		))
+		Expect(sw.BuildYAML()).To(ContainSubstring(`      parameters:
+        - name: ids
+          in: query
+          style: form
+          explode: false
+          schema:
+            type: array
+            items:
+              type: string
+        - name: colors
+          in: query
+          style: pipeDelimited
+          schema:
+            type: array
+            items:
+              type: string
+        - name: filter
+          in: query
+          style: deepObject
+          explode: true
+          schema:
+            type: object
+            properties:
+              name:
+                type: string
+              age:
+                type: integer
+                format: int64
+      responses:
+`))
+	})
+
+	It("panics for a parameter style that is invalid for its location", func() {
+		sw.Add(sashay.NewOperation(
+			"GET",
+			"/users",
+			"",
+			struct {
+				Session string `header:"X-Session" style:"deepObject"`
+			}{},
+			nil,
+			nil,
+		))
+		Expect(func() {
+			sw.BuildYAML()
+		}).To(Panic())
+	})
+
+	It("can use an alternative global content type", func() {
+		sw.DefaultContentType = "application/myapp+json+v1"
+		sw.Add(sashay.NewOperation(
+			"POST",
+			"/users/:id",
+			"Update the user",
+			struct {
+				ID   string `path:"id"`
+				Name string `json:"name"`
+			}{},
+			User{},
+			ErrorModel{},
+		))
+		Expect(sw.BuildYAML()).To(ContainSubstring(`paths:
+  /users/{id}:
+    post:
+      operationId: postUsersId
+      summary: Update the user
+      parameters:
+        - name: id
+          in: path
           required: true
           schema:
             type: string
@@ -678,6 +1375,296 @@ security:
 `))
 	})
 
+	It("uses multipart/form-data for request bodies with file form fields", func() {
+		sw.Add(sashay.NewOperation(
+			"POST",
+			"/users/:id/avatar",
+			"Upload an avatar.",
+			struct {
+				ID      int                     `path:"id"`
+				Caption string                  `form:"caption"`
+				Avatar  *multipart.FileHeader   `form:"avatar" contentType:"image/png, image/jpeg"`
+				Extras  []*multipart.FileHeader `form:"extras"`
+				Ignored string                  `json:"ignored"`
+			}{},
+			nil,
+			nil,
+		))
+		Expect(sw.BuildYAML()).To(ContainSubstring(`      requestBody:
+        required: true
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                caption:
+                  type: string
+                avatar:
+                  type: string
+                  format: binary
+                extras:
+                  type: array
+                  items:
+                    type: string
+                    format: binary
+            encoding:
+              avatar:
+                contentType: image/png, image/jpeg
+      responses:
+`))
+	})
+
+	It("uses application/x-www-form-urlencoded for request bodies with form fields", func() {
+		sw.Add(sashay.NewOperation(
+			"POST",
+			"/login",
+			"Log in.",
+			struct {
+				Username string `form:"username"`
+				Password string `form:"password"`
+			}{},
+			nil,
+			nil,
+		))
+		Expect(sw.BuildYAML()).To(ContainSubstring(`      requestBody:
+        required: true
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                username:
+                  type: string
+                password:
+                  type: string
+      responses:
+`))
+	})
+
+	Describe("operation IDs", func() {
+		It("can be set explicitly", func() {
+			sw.Add(sashay.NewOperation("GET", "/users/:id", "", nil, nil, nil).WithOperationID("fetchUser"))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`    get:
+      operationId: fetchUser
+`))
+		})
+
+		It("can use a custom strategy", func() {
+			sw.OperationIDer = func(op sashay.Operation) sashay.OperationID {
+				return sashay.OperationID("v1_" + string(sashay.NewOperationID(op)))
+			}
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, nil))
+			sw.Add(sashay.NewOperation("POST", "/users", "", nil, nil, nil).WithOperationID("createUser"))
+			result := sw.BuildYAML()
+			Expect(result).To(ContainSubstring(`operationId: v1_getUsers`))
+			Expect(result).To(ContainSubstring(`operationId: createUser`))
+		})
+
+		It("errors when two operations have the same ID", func() {
+			sw.Add(sashay.NewOperation("GET", "/users/:id", "", nil, nil, nil))
+			sw.Add(sashay.NewOperation("GET", "/users/id", "", nil, nil, nil))
+			err := sw.Validate()
+			Expect(err).To(MatchError(`operations GET /users/:id and GET /users/id have the same operationId "getUsersId", ` +
+				`use Operation.OperationID to give one of them a different ID`))
+			Expect(sw.WriteYAML(bytes.NewBuffer(nil))).To(MatchError(err))
+			Expect(func() { sw.BuildYAML() }).To(PanicWith(err))
+		})
+
+		It("does not overwrite a file when two operations have the same ID", func() {
+			f, err := ioutil.TempFile("", "sashay")
+			Expect(err).To(Not(HaveOccurred()))
+			defer os.Remove(f.Name())
+			Expect(ioutil.WriteFile(f.Name(), []byte("existing spec"), 0644)).To(Succeed())
+
+			sw.Add(sashay.NewOperation("GET", "/users/:id", "", nil, nil, nil))
+			sw.Add(sashay.NewOperation("GET", "/users/id", "", nil, nil, nil))
+			Expect(sw.WriteYAMLFile(f.Name())).To(HaveOccurred())
+			contents, err := ioutil.ReadFile(f.Name())
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(string(contents)).To(Equal("existing spec"))
+		})
+	})
+
+	Describe("path-level parameters", func() {
+		type orgParams struct {
+			OrgID string `path:"orgId"`
+		}
+		type teamParams struct {
+			OrgID  string `path:"orgId"`
+			TeamID int    `path:"teamId"`
+		}
+
+		It("writes parameters added for a path prefix once per matching path", func() {
+			sw.AddPathParams("/orgs/:orgId", orgParams{})
+			sw.Add(sashay.NewOperation("GET", "/orgs/:orgId", "", orgParams{}, nil, nil))
+			sw.Add(sashay.NewOperation("GET", "/orgs/:orgId/teams/:teamId", "", teamParams{}, nil, nil))
+			sw.Add(sashay.NewOperation("GET", "/orgsettings", "", nil, nil, nil))
+			result := sw.BuildYAML()
+			Expect(result).To(ContainSubstring(`  /orgs/{orgId}:
+    parameters:
+      - name: orgId
+        in: path
+        required: true
+        schema:
+          type: string
+    get:
+      operationId: getOrgsOrgId
+      responses:
+`))
+			Expect(result).To(ContainSubstring(`  /orgs/{orgId}/teams/{teamId}:
+    parameters:
+      - name: orgId
+        in: path
+        required: true
+        schema:
+          type: string
+    get:
+      operationId: getOrgsOrgIdTeamsTeamId
+      parameters:
+        - name: teamId
+          in: path
+          required: true
+          schema:
+            type: integer
+            format: int64
+      responses:
+`))
+			Expect(result).To(ContainSubstring(`  /orgsettings:
+    get:
+`))
+		})
+
+		It("writes operation parameters that differ from the shared ones as overrides", func() {
+			sw.AddPathParams("/orgs/:orgId", orgParams{})
+			sw.Add(sashay.NewOperation("GET", "/orgs/:orgId", "", struct {
+				OrgID string `path:"orgId" description:"Org slug."`
+			}{}, nil, nil))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`    get:
+      operationId: getOrgsOrgId
+      parameters:
+        - name: orgId
+          in: path
+          required: true
+          description: Org slug.
+          schema:
+            type: string
+`))
+		})
+
+		It("can share parameters common to all operations on a path", func() {
+			sw.ShareCommonParams = true
+			sw.Add(sashay.NewOperation("GET", "/orgs/:orgId/teams/:teamId", "", teamParams{}, nil, nil))
+			sw.Add(sashay.NewOperation("DELETE", "/orgs/:orgId/teams/:teamId", "", struct {
+				TeamID int    `path:"teamId"`
+				OrgID  string `path:"orgId"`
+				Force  bool   `query:"force"`
+			}{}, nil, nil))
+			sw.Add(sashay.NewOperation("GET", "/orgs/:orgId", "", orgParams{}, nil, nil))
+			result := sw.BuildYAML()
+			Expect(result).To(ContainSubstring(`  /orgs/{orgId}:
+    get:
+      operationId: getOrgsOrgId
+      parameters:
+        - name: orgId
+`))
+			Expect(result).To(ContainSubstring(`  /orgs/{orgId}/teams/{teamId}:
+    parameters:
+      - name: orgId
+        in: path
+        required: true
+        schema:
+          type: string
+      - name: teamId
+        in: path
+        required: true
+        schema:
+          type: integer
+          format: int64
+    get:
+      operationId: getOrgsOrgIdTeamsTeamId
+      responses:
+`))
+			Expect(result).To(ContainSubstring(`    delete:
+      operationId: deleteOrgsOrgIdTeamsTeamId
+      parameters:
+        - name: force
+          in: query
+          schema:
+            type: boolean
+      responses:
+`))
+		})
+	})
+
+	Describe("request body usage", func() {
+		params := struct {
+			Name string `json:"name"`
+		}{}
+		const nameBody = `      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string
+`
+
+		It("includes a requestBody for PATCH", func() {
+			sw.Add(sashay.NewOperation("PATCH", "/users", "", params, nil, nil))
+			Expect(sw.BuildYAML()).To(ContainSubstring("operationId: patchUsers\n" + nameBody))
+		})
+
+		It("can force a requestBody for any method", func() {
+			sw.Add(sashay.NewOperation("DELETE", "/users", "", params, nil, nil).WithRequestBody())
+			Expect(sw.BuildYAML()).To(ContainSubstring("operationId: deleteUsers\n" + nameBody))
+		})
+
+		It("can suppress a requestBody for any method", func() {
+			sw.Add(sashay.NewOperation("POST", "/users", "", params, nil, nil).WithoutRequestBody())
+			Expect(sw.BuildYAML()).To(ContainSubstring(`operationId: postUsers
+      responses:
+`))
+		})
+
+		It("can use a JSON Merge Patch content type", func() {
+			sw.Add(sashay.NewOperation("PATCH", "/users", "", params, nil, nil).
+				WithRequestContentType(sashay.ContentTypeMergePatch))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`        content:
+          application/merge-patch+json:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string
+`))
+		})
+
+		It("documents a JSON Patch document for the JSON Patch content type", func() {
+			sw.Add(sashay.NewOperation("PATCH", "/users", "", params, nil, nil).
+				WithRequestContentType(sashay.ContentTypeJSONPatch))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`        content:
+          application/json-patch+json:
+            schema:
+              type: array
+              items:
+                type: object
+                required: [op, path]
+                properties:
+                  op:
+                    type: string
+                    enum: [add, remove, replace, move, copy, test]
+                  path:
+                    type: string
+                  value: {}
+                  from:
+                    type: string
+      responses:
+`))
+		})
+	})
+
 	It("does not include requestBody for POST/PUT with no parameters", func() {
 		sw.Add(sashay.NewOperation(
 			"POST",
@@ -976,19 +1963,170 @@ security:
 		Expect(yaml).To(Not(ContainSubstring("/Time"))) // No $ref link
 	})
 
-	It("can use custom data type definitions", func() {
-		type Custom struct {
-			Field string `json:"field"`
-		}
-		type Response struct {
-			Custom Custom `json:"custom"`
+	Describe("examples", func() {
+		type Pet struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
 		}
-		sw.DefineDataType(Custom{}, sashay.SimpleDataTyper("boolean", ""))
-		sw.Add(sashay.NewOperation(
-			"POST",
-			"/stuff",
-			"Updates stuff.",
-			struct {
+
+		It("can use populated values as examples", func() {
+			sw.ExamplesFromValues = true
+			sw.DefineSchemaExample(Pet{ID: 5, Name: "Spot"})
+			sw.Add(sashay.NewOperation(
+				"POST",
+				"/pets/:id",
+				"",
+				struct {
+					ID     int    `path:"id"`
+					Pretty bool   `query:"pretty" example:"true"`
+					Name   string `json:"name"`
+					Age    int    `json:"age"`
+				}{ID: 5, Name: "Spot"},
+				Pet{ID: 5, Name: "Spot"},
+				ErrorModel{},
+			))
+			yaml := sw.BuildYAML()
+			Expect(yaml).To(ContainSubstring(`      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+            format: int64
+          example: 5
+        - name: pretty
+          in: query
+          schema:
+            type: boolean
+          example: true
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string
+                age:
+                  type: integer
+                  format: int64
+            example: {"name":"Spot","age":0}
+      responses:
+        '201':
+          description: ok response
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+              example: {"id":5,"name":"Spot"}
+        'default':
+          description: error response
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorModel'
+components:
+`))
+			Expect(yaml).To(ContainSubstring(`    Pet:
+      type: object
+      properties:
+        id:
+          type: integer
+          format: int64
+        name:
+          type: string
+      example: {"id":5,"name":"Spot"}
+`))
+		})
+
+		It("only includes the json-named fields of nested structs in value examples", func() {
+			type Owner struct {
+				Name     string `json:"name"`
+				Password string
+			}
+			type Household struct {
+				Owner  Owner   `json:"owner"`
+				Pets   []Owner `json:"pets"`
+				Sitter *Owner  `json:"sitter"`
+			}
+			sw.ExamplesFromValues = true
+			owner := Owner{Name: "Rob", Password: "secret"}
+			sw.Add(sashay.Operation{
+				Method:   "PUT",
+				Path:     "/household",
+				Body:     map[string]Owner{"rob": owner},
+				ReturnOk: Household{Owner: owner, Pets: []Owner{owner}, Sitter: &owner},
+			})
+			yaml := sw.BuildYAML()
+			Expect(yaml).To(ContainSubstring(`
+            example: {"rob":{"name":"Rob"}}
+`))
+			Expect(yaml).To(ContainSubstring(`
+              example: {"owner":{"name":"Rob"},"pets":[{"name":"Rob"}],"sitter":{"name":"Rob"}}
+`))
+			Expect(yaml).To(Not(ContainSubstring("secret")))
+		})
+
+		It("uses the values that struct pointers point to in value examples", func() {
+			sw.ExamplesFromValues = true
+			sw.Add(sashay.NewOperation("GET", "/pets/:id", "", nil, &Pet{ID: 5, Name: "Spot"}, nil))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`
+              example: {"id":5,"name":"Spot"}
+`))
+		})
+
+		It("does not use values as examples by default", func() {
+			sw.Add(sashay.NewOperation("GET", "/pets", "", nil, Pet{ID: 5}, nil))
+			Expect(sw.BuildYAML()).To(Not(ContainSubstring("example")))
+		})
+
+		It("can use named examples", func() {
+			sw.Add(sashay.NewOperation(
+				"PUT",
+				"/pets",
+				"",
+				Pet{},
+				sashay.NewResponse(200, "ok", Pet{}).WithExamples(sashay.Examples{
+					"spot": Pet{ID: 1, Name: "Spot"},
+					"fido": Pet{ID: 2, Name: "Fido"},
+				}),
+				nil,
+			).WithRequestExamples(sashay.Examples{"rename": map[string]string{"name": "Rex"}}))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`                name:
+                  type: string
+            examples:
+              rename:
+                value: {"name":"Rex"}
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+              examples:
+                fido:
+                  value: {"id":2,"name":"Fido"}
+                spot:
+                  value: {"id":1,"name":"Spot"}
+`))
+		})
+	})
+
+	It("can use custom data type definitions", func() {
+		type Custom struct {
+			Field string `json:"field"`
+		}
+		type Response struct {
+			Custom Custom `json:"custom"`
+		}
+		sw.DefineDataType(Custom{}, sashay.SimpleDataTyper("boolean", ""))
+		sw.Add(sashay.NewOperation(
+			"POST",
+			"/stuff",
+			"Updates stuff.",
+			struct {
 				PCustom Custom `json:"pcustom"`
 			}{},
 			Response{},
@@ -1243,6 +2381,236 @@ components:
 `))
 	})
 
+	Describe("explicit request bodies", func() {
+		It("uses Body for the request body and Params for parameters", func() {
+			sw.Add(sashay.Operation{
+				Method: "POST",
+				Path:   "/users/:id/addresses",
+				Params: struct {
+					ID     int  `path:"id"`
+					Pretty bool `query:"pretty"`
+				}{},
+				Body: []Address{},
+			})
+			yaml := sw.BuildYAML()
+			Expect(yaml).To(ContainSubstring(`      operationId: postUsersIdAddresses
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+            format: int64
+        - name: pretty
+          in: query
+          schema:
+            type: boolean
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: array
+              items:
+                $ref: '#/components/schemas/Address'
+      responses:
+`))
+			Expect(yaml).To(ContainSubstring(`components:
+  schemas:
+    Address:
+      type: object
+`))
+		})
+
+		It("writes structs referenced by nested slice and map bodies into components", func() {
+			sw.Add(sashay.Operation{Method: "POST", Path: "/addresses", Body: [][]Address{}})
+			sw.Add(sashay.Operation{Method: "POST", Path: "/errors", Body: map[string][]ErrorModel{}})
+			yaml := sw.BuildYAML()
+			Expect(yaml).To(ContainSubstring(`            schema:
+              type: array
+              items:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Address'
+`))
+			Expect(yaml).To(ContainSubstring(`            schema:
+              type: object
+              additionalProperties:
+                type: array
+                items:
+                  $ref: '#/components/schemas/ErrorModel'
+`))
+			Expect(yaml).To(ContainSubstring(`components:
+  schemas:
+    Address:
+      type: object
+`))
+			Expect(yaml).To(ContainSubstring(`    ErrorModel:
+      type: object
+`))
+		})
+
+		It("can use struct, map, string, and byte slice bodies", func() {
+			sw.Add(sashay.Operation{Method: "POST", Path: "/struct", Body: struct {
+				Name string `json:"name"`
+			}{}})
+			sw.Add(sashay.Operation{Method: "POST", Path: "/map", Body: map[string]int{}})
+			sw.Add(sashay.Operation{Method: "POST", Path: "/text", Body: ""})
+			sw.Add(sashay.Operation{Method: "PUT", Path: "/bytes", Body: []byte{}})
+			yaml := sw.BuildYAML()
+			Expect(yaml).To(ContainSubstring(`      operationId: putBytes
+      requestBody:
+        required: true
+        content:
+          application/octet-stream:
+            schema:
+              type: string
+              format: binary
+`))
+			Expect(yaml).To(ContainSubstring(`      operationId: postMap
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              additionalProperties:
+                type: integer
+                format: int64
+`))
+			Expect(yaml).To(ContainSubstring(`      operationId: postStruct
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string
+`))
+			Expect(yaml).To(ContainSubstring(`      operationId: postText
+      requestBody:
+        required: true
+        content:
+          text/plain:
+            schema:
+              type: string
+`))
+		})
+
+		It("uses Body for any method", func() {
+			sw.Add(sashay.NewOperation("DELETE", "/users", "", nil, nil, nil).WithBody([]int{}))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`      operationId: deleteUsers
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: array
+              items:
+                type: integer
+                format: int64
+`))
+		})
+	})
+
+	Describe("request body refs", func() {
+		BeforeEach(func() {
+			sw.Add(sashay.NewOperation("POST", "/users/:id", "", CreateUserParams{}, nil, nil))
+			sw.Add(sashay.NewOperation("PUT", "/users/:id", "", CreateUserParams{}, nil, nil))
+			sw.Add(sashay.NewOperation("PATCH", "/users/:id", "", struct {
+				Name string `json:"name"`
+			}{}, nil, nil))
+		})
+
+		It("can write exported request bodies as schema components", func() {
+			sw.RequestBodyRefs = sashay.RequestBodySchemaRefs
+			yaml := sw.BuildYAML()
+			Expect(yaml).To(ContainSubstring(`    put:
+      operationId: putUsersId
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+            format: int64
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/CreateUserParams'
+`))
+			Expect(yaml).To(ContainSubstring(`    patch:
+      operationId: patchUsersId
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+`))
+			Expect(yaml).To(HaveSuffix(`components:
+  schemas:
+    Address:
+      type: object
+      properties:
+        city:
+          type: string
+    CreateUserParams:
+      type: object
+      properties:
+        name:
+          type: string
+        address:
+          $ref: '#/components/schemas/Address'
+`))
+		})
+
+		It("can write exported request bodies as request body components", func() {
+			sw.RequestBodyRefs = sashay.RequestBodyComponentRefs
+			yaml := sw.BuildYAML()
+			Expect(yaml).To(ContainSubstring(`    post:
+      operationId: postUsersId
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+            format: int64
+      requestBody:
+        $ref: '#/components/requestBodies/CreateUserParams'
+`))
+			Expect(yaml).To(ContainSubstring(`        address:
+          $ref: '#/components/schemas/Address'
+  requestBodies:
+    CreateUserParams:
+      required: true
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/CreateUserParams'
+`))
+		})
+
+		It("writes request bodies with another content type than the component inline", func() {
+			sw.RequestBodyRefs = sashay.RequestBodyComponentRefs
+			sw.Add(sashay.NewOperation("POST", "/users", "", CreateUserParams{}, nil, nil).
+				WithRequestContentType("application/xml"))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`            format: int64
+      requestBody:
+        required: true
+        content:
+          application/xml:
+            schema:
+              $ref: '#/components/schemas/CreateUserParams'
+`))
+		})
+	})
+
 	It("can create a new registry by filtering/mapping operations", func() {
 		sw.DefaultContentType = "application/xml"
 		sw.AddServer("server.com", "the server")
@@ -1275,6 +2643,410 @@ paths:
 `))
 	})
 
+	Describe("callbacks and webhooks", func() {
+		type Event struct {
+			Type string  `json:"type"`
+			Pet  Address `json:"pet"`
+		}
+		type SubscribeParams struct {
+			CallbackURL string `json:"callbackUrl"`
+		}
+
+		It("writes callbacks on operations", func() {
+			sw.Add(sashay.NewOperation("POST", "/subscriptions", "Subscribe.", SubscribeParams{}, nil, nil).
+				AddCallback("onEvent", "{$request.body#/callbackUrl}",
+					sashay.Operation{Summary: "Event notification.", Params: Event{}}))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`      callbacks:
+        onEvent:
+          '{$request.body#/callbackUrl}':
+            post:
+              summary: Event notification.
+              requestBody:
+                required: true
+                content:
+                  application/json:
+                    schema:
+                      type: object
+                      properties:
+                        type:
+                          type: string
+                        pet:
+                          type: object
+                          properties:
+                            city:
+                              type: string
+              responses:
+                '204':
+                  description: The operation completed successfully.
+                'default':
+                  description: error response
+`))
+		})
+
+		It("writes webhooks for OpenAPI 3.1", func() {
+			sw.OpenAPIVersion = sashay.OpenAPIVersion31
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, nil))
+			sw.AddWebhook("newPet", sashay.Operation{
+				OperationID: "newPetWebhook",
+				Body:        Address{},
+				ReturnOk:    sashay.NewResponse(200, "Received.", nil),
+			})
+			Expect(sw.BuildYAML()).To(ContainSubstring(`          description: error response
+webhooks:
+  newPet:
+    post:
+      operationId: newPetWebhook
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                city:
+                  type: string
+      responses:
+        '200':
+          description: Received.
+        'default':
+          description: error response
+`))
+		})
+
+		It("writes schemas used by callbacks into components", func() {
+			sw.Add(sashay.NewOperation("POST", "/subscriptions", "", SubscribeParams{}, nil, nil).
+				AddCallback("onEvent", "{$request.body#/callbackUrl}", sashay.Operation{ReturnErr: ErrorModel{}}))
+			result := sw.BuildYAML()
+			Expect(result).To(ContainSubstring(`                'default':
+                  description: error response
+                  content:
+                    application/json:
+                      schema:
+                        $ref: '#/components/schemas/ErrorModel'
+`))
+			Expect(result).To(ContainSubstring(`components:
+  schemas:
+    ErrorModel:
+`))
+		})
+
+		It("panics for webhooks in OpenAPI 3.0", func() {
+			sw.AddWebhook("newPet", sashay.Operation{Body: Address{}})
+			Expect(func() { sw.BuildYAML() }).To(PanicWith(
+				`webhook "newPet" requires OpenAPIVersion 3.1, use Operation#AddCallback for OpenAPI 3.0`))
+		})
+
+		It("panics for callbacks without a name or expression", func() {
+			op := sashay.NewOperation("POST", "/subscriptions", "", SubscribeParams{}, nil, nil)
+			Expect(func() {
+				op.AddCallback("", "{$request.body#/callbackUrl}", sashay.Operation{})
+			}).To(PanicWith(`callback for expression "{$request.body#/callbackUrl}" must have a name`))
+			Expect(func() {
+				op.AddCallback("onEvent", "", sashay.Operation{})
+			}).To(PanicWith(`callback "onEvent" must have an expression`))
+			op.Callbacks = []sashay.Callback{{Name: "onEvent"}}
+			Expect(func() {
+				sw.Add(op)
+			}).To(PanicWith(`callback "onEvent" must have an expression`))
+		})
+
+		It("panics for webhooks without a name", func() {
+			Expect(func() {
+				sw.AddWebhook("", sashay.Operation{Body: Address{}})
+			}).To(PanicWith("webhook must have a name"))
+		})
+
+		It("errors for duplicate operation IDs", func() {
+			sw.OpenAPIVersion = sashay.OpenAPIVersion31
+			sw.Add(sashay.NewOperation("GET", "/pets", "", nil, nil, nil))
+			sw.AddWebhook("newPet", sashay.Operation{OperationID: "getPets"})
+			Expect(sw.Validate()).To(MatchError(`operationId "getPets" is used more than once, including by a webhook or callback`))
+		})
+	})
+
+	Describe("response links", func() {
+		type getUserParams struct {
+			ID int `path:"id"`
+		}
+		getUser := sashay.NewOperation("GET", "/users/:id", "", getUserParams{}, User{}, nil)
+
+		It("link to operations by value or operationId", func() {
+			sw.Add(getUser)
+			sw.Add(sashay.NewOperation("DELETE", "/users/:id", "", getUserParams{}, nil, nil).WithOperationID("removeUser"))
+			sw.Add(sashay.NewOperation("POST", "/users", "", nil, sashay.NewResponse(201, "created", User{}).AddLinks(
+				sashay.NewLink("GetUserByID", getUser, map[string]string{"id": "$response.body#/result/id"}),
+				sashay.Link{
+					Name:        "DeleteUser",
+					Description: "Delete the created user.",
+					OperationID: "removeUser",
+					Parameters:  map[string]string{"path.id": "$response.body#/result/id"},
+				},
+			), nil))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`        '201':
+          description: created
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+          links:
+            GetUserByID:
+              operationId: getUsersId
+              parameters:
+                id: "$response.body#/result/id"
+            DeleteUser:
+              operationId: removeUser
+              parameters:
+                path.id: "$response.body#/result/id"
+              description: Delete the created user.
+`))
+		})
+
+		It("quotes parameter values", func() {
+			sw.Add(getUser)
+			sw.Add(sashay.NewOperation("POST", "/users", "", nil, sashay.NewResponse(201, "created", User{}).AddLinks(
+				sashay.NewLink("GetUserByID", getUser, map[string]string{"id": `it's "quoted"`}),
+			), nil))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`                id: "it's \"quoted\""
+`))
+		})
+
+		It("errors for invalid names", func() {
+			for _, name := range []string{"", "Get User", "get:user"} {
+				sw := sashay.New("SwaggerGenAPI", "", "")
+				sw.Add(getUser)
+				sw.Add(sashay.NewOperation("POST", "/users", "", nil, sashay.NewResponse(201, "created", User{}).AddLinks(
+					sashay.NewLink(name, getUser, nil),
+				), nil))
+				Expect(sw.Validate()).To(MatchError(fmt.Sprintf("link name %q must not be empty, "+
+					"and can only use letters, digits, '.', '-', and '_'", name)))
+			}
+		})
+
+		It("errors if the target is not added", func() {
+			sw.Add(sashay.NewOperation("POST", "/users", "", nil, sashay.NewResponse(201, "created", User{}).AddLinks(
+				sashay.NewLink("GetUserByID", getUser, nil),
+			), nil))
+			Expect(sw.Validate()).To(MatchError(`link "GetUserByID" targets GET /users/:id, which is not added to the Sashay`))
+		})
+
+		It("errors if the target is a webhook or callback", func() {
+			sw.OpenAPIVersion = sashay.OpenAPIVersion31
+			sw.AddWebhook("newUser", sashay.Operation{OperationID: "newUserHook", Body: User{}})
+			sw.Add(sashay.NewOperation("POST", "/users", "", nil, sashay.NewResponse(201, "created", User{}).AddLinks(
+				sashay.NewLinkToID("NewUser", "newUserHook", nil),
+			), nil))
+			Expect(sw.Validate()).To(MatchError(`link "NewUser" targets operationId "newUserHook", which is not added to the Sashay`))
+		})
+
+		It("are dropped if their target was removed with WithoutDeprecated", func() {
+			sw.Add(getUser.WithDeprecated())
+			sw.Add(sashay.NewOperation("DELETE", "/users/:id", "", getUserParams{}, nil, nil).
+				WithOperationID("removeUser").
+				WithDeprecated())
+			sw.Add(sashay.NewOperation("POST", "/users", "", nil, sashay.NewResponse(201, "created", User{}).AddLinks(
+				sashay.NewLink("GetUserByID", getUser, nil),
+				sashay.NewLinkToID("DeleteUser", "removeUser", nil),
+			), nil))
+			Expect(sashay.WithoutDeprecated(sw).BuildYAML()).To(ContainSubstring(`        '201':
+          description: created
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+        'default':
+          description: error response
+components:
+`))
+		})
+
+		It("target operations whose path SelectMap rewrote", func() {
+			sw.Add(getUser)
+			sw.Add(sashay.NewOperation("POST", "/users", "", nil, sashay.NewResponse(201, "created", User{}).AddLinks(
+				sashay.NewLink("GetUserByID", getUser, map[string]string{"id": "$response.body#/result/id"}),
+				sashay.NewLinkToID("GetUser", "getUsersId", nil),
+			), nil))
+			v2 := sashay.SelectMap(sw, func(op sashay.Operation) *sashay.Operation {
+				op.Path = "/v2" + op.Path
+				return &op
+			})
+			Expect(v2.Validate()).To(Succeed())
+			Expect(v2.BuildYAML()).To(ContainSubstring(`          links:
+            GetUserByID:
+              operationId: getV2UsersId
+              parameters:
+                id: "$response.body#/result/id"
+            GetUser:
+              operationId: getV2UsersId
+`))
+		})
+
+		It("errors if the target does not have a mapped parameter", func() {
+			sw.Add(getUser)
+			sw.Add(sashay.NewOperation("POST", "/users", "", nil, sashay.NewResponse(201, "created", User{}).AddLinks(
+				sashay.NewLinkToID("GetUserByID", "getUsersId", map[string]string{"query.id": "$response.body#/result/id"}),
+			), nil))
+			Expect(sw.Validate()).To(MatchError(`link "GetUserByID" maps parameter "query.id", which GET /users/:id does not have`))
+		})
+	})
+
+	Describe("external docs", func() {
+		It("are written for the document, tags, and operations", func() {
+			sw.SetExternalDocs("https://docs.example.com", "Guides")
+			sw.DefineTag(sashay.Tag{
+				Name:         "user",
+				Description:  "Users",
+				ExternalDocs: sashay.ExternalDocs{URL: "https://docs.example.com/users"},
+			})
+			sw.Add(sashay.NewOperation("DELETE", "/users", "", nil, nil, nil).
+				WithExternalDocs("https://runbooks.example.com/delete-users", "Runbook"))
+			result := sw.BuildYAML()
+			Expect(result).To(ContainSubstring(`tags:
+  - name: user
+    description: Users
+    externalDocs:
+      url: https://docs.example.com/users
+externalDocs:
+  url: https://docs.example.com
+  description: Guides
+paths:
+  /users:
+    delete:
+      operationId: deleteUsers
+      externalDocs:
+        url: https://runbooks.example.com/delete-users
+        description: Runbook
+      responses:
+`))
+		})
+
+		It("panic without a URL", func() {
+			sw.SetExternalDocs("", "Guides")
+			Expect(func() { sw.BuildYAML() }).To(PanicWith(`externalDocs "Guides" must have a URL`))
+		})
+	})
+
+	Describe("vendor extensions", func() {
+		It("are written at every level of the document", func() {
+			sw.Extensions = sashay.Extensions{"x-tagGroups": []map[string]interface{}{{"name": "Users", "tags": []string{"user"}}}}
+			sw.InfoExtensions = sashay.Extensions{"x-logo": map[string]string{"url": "logo.png"}}
+			sw.DefineTag(sashay.Tag{Name: "user", Description: "Users", Extensions: sashay.Extensions{"x-displayName": "Users"}})
+			sw.DefineServer(sashay.Server{URL: "https://api.example.com", Description: "Prod", Extensions: sashay.Extensions{"x-region": "us"}})
+			sw.DefineSchemaExtensions(User{}, sashay.Extensions{"x-go-type": "models.User"})
+			sw.Add(sashay.NewOperation("GET", "/users/:id", "", struct {
+				ID int `path:"id" x-go-name:"UserID"`
+			}{}, sashay.NewResponse(200, "ok", User{}).WithExtensions(sashay.Extensions{"x-cache": true}), nil).
+				WithExtensions(sashay.Extensions{"x-rate-limit": 100}))
+			result := sw.BuildYAML()
+			Expect(result).To(ContainSubstring(`  version: 0.1.9
+  x-logo: {"url":"logo.png"}
+tags:
+  - name: user
+    description: Users
+    x-displayName: "Users"
+servers:
+  - url: https://api.example.com
+    description: Prod
+    x-region: "us"
+`))
+			Expect(result).To(ContainSubstring(`        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+            format: int64
+          x-go-name: UserID
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+          x-cache: true
+`))
+			Expect(result).To(ContainSubstring(`          description: error response
+      x-rate-limit: 100
+`))
+			Expect(result).To(ContainSubstring(`              type: string
+      x-go-type: "models.User"
+`))
+			Expect(result).To(HaveSuffix(`x-tagGroups: [{"name":"Users","tags":["user"]}]
+`))
+		})
+
+		It("are written for x- struct tags on properties", func() {
+			sw.Add(sashay.NewOperation("POST", "/users", "", struct {
+				Name string `json:"name" x-go-name:"FullName"`
+			}{}, nil, nil))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`                name:
+                  type: string
+                  x-go-name: FullName
+`))
+		})
+
+		It("are not written for x- struct tags on properties that are $refs", func() {
+			type Account struct {
+				Name    string  `json:"name" x-go-name:"FullName"`
+				Address Address `json:"address" x-go-name:"HomeAddress"`
+			}
+			sw.Add(sashay.NewOperation("GET", "/account", "", nil, Account{}, nil))
+			result := sw.BuildYAML()
+			Expect(result).To(ContainSubstring(`    Account:
+      type: object
+      properties:
+        name:
+          type: string
+          x-go-name: FullName
+        address:
+          $ref: '#/components/schemas/Address'
+`))
+			Expect(result).To(Not(ContainSubstring("x-go-name: HomeAddress")))
+		})
+
+		It("panic for keys not starting with x-", func() {
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, nil).WithExtensions(sashay.Extensions{"rate-limit": 1}))
+			Expect(func() { sw.BuildYAML() }).To(PanicWith(`extension "rate-limit" must start with x-`))
+		})
+	})
+
+	Describe("deprecated operations", func() {
+		It("are marked as deprecated with a note in their description", func() {
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, nil).
+				WithDescription("List users.").
+				WithSunset(time.Date(2030, 1, 15, 0, 0, 0, 0, time.UTC)).
+				WithReplacement(sashay.NewOperation("GET", "/v2/users", "", nil, nil, nil)))
+			sw.Add(sashay.NewOperation("DELETE", "/users", "", nil, nil, nil).WithDeprecated())
+			result := sw.BuildYAML()
+			Expect(result).To(ContainSubstring(`    get:
+      operationId: getUsers
+      description: List users. This operation is deprecated and will be removed on 2030-01-15. Use GET /v2/users instead.
+      deprecated: true
+`))
+			Expect(result).To(ContainSubstring(`    delete:
+      operationId: deleteUsers
+      description: This operation is deprecated.
+      deprecated: true
+`))
+		})
+
+		It("note their replacement with its OpenAPI path", func() {
+			sw.Add(sashay.NewOperation("GET", "/users/:id", "", CreateUserParams{}, nil, nil).
+				WithReplacement(sashay.NewOperation("GET", "/v2/users/:id", "", CreateUserParams{}, nil, nil)))
+			Expect(sw.BuildYAML()).To(ContainSubstring(`
+      description: This operation is deprecated. Use GET /v2/users/{id} instead.
+`))
+		})
+
+		It("can be removed from the registry", func() {
+			sw.Add(sashay.NewOperation("GET", "/users", "", nil, nil, nil).WithDeprecated())
+			sw.Add(sashay.NewOperation("GET", "/v2/users", "", nil, nil, nil))
+			result := sashay.WithoutDeprecated(sw).BuildYAML()
+			Expect(result).To(ContainSubstring("\n  /v2/users:"))
+			Expect(result).To(Not(ContainSubstring("\n  /users:")))
+			Expect(sw.BuildYAML()).To(ContainSubstring("\n  /users:"))
+		})
+	})
+
 	It("writes to a file", func() {
 		f, err := ioutil.TempFile("", "sashay")
 		Expect(err).To(Not(HaveOccurred()))
@@ -1299,8 +3071,9 @@ paths:
     get:
       operationId: getUsers
       requestBody:
+        required: true
         content:
-          */*:
+          application/json:
             schema:
               type: object
       responses:
@@ -1331,10 +3104,12 @@ paths:
     get:
       operationId: getUsers
       requestBody:
+        required: true
         content:
-          */*:
+          application/json:
             schema:
               type: array
+              items: {}
       responses:
         '200':
           description: ok response
@@ -1367,8 +3142,9 @@ paths:
     get:
       operationId: getUsers
       requestBody:
+        required: true
         content:
-          */*:
+          application/json:
             schema:
               type: object
       responses:
@@ -1385,6 +3161,56 @@ paths:
               schema:
                 type: object`))
 	})
+
+	It("writes a single complete request body for non-struct parameters", func() {
+		sw.Add(sashay.NewOperation("POST", "/users", "", []User{}, nil, nil))
+		sw.Add(sashay.NewOperation("PUT", "/users", "", map[string][]int{}, nil, nil))
+		sw.Add(sashay.NewOperation("PATCH", "/users", "", 0, nil, nil))
+		yaml := sw.BuildYAML()
+		Expect(yaml).To(ContainSubstring(`    post:
+      operationId: postUsers
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: array
+              items:
+                $ref: '#/components/schemas/User'
+      responses:
+`))
+		Expect(yaml).To(ContainSubstring(`    put:
+      operationId: putUsers
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              additionalProperties:
+                type: array
+                items:
+                  type: integer
+                  format: int64
+      responses:
+`))
+		Expect(yaml).To(ContainSubstring(`    patch:
+      operationId: patchUsers
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: integer
+              format: int64
+      responses:
+`))
+		Expect(yaml).To(ContainSubstring(`components:
+  schemas:
+    User:
+`))
+	})
+
 	It("can handle nested generic objects", func() {
 		type t struct {
 			Map      map[string]interface{}   `json:"map"`
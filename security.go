@@ -0,0 +1,234 @@
+package sashay
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// OAuth2 flow types.
+// See https://swagger.io/specification/#oauthFlowsObject
+const (
+	OAuthFlowAuthorizationCode = "authorizationCode"
+	OAuthFlowClientCredentials = "clientCredentials"
+	OAuthFlowImplicit          = "implicit"
+	OAuthFlowPassword          = "password"
+)
+
+// OAuthFlow describes an OAuth2 flow for AddOAuth2Security.
+// See https://swagger.io/specification/#oauthFlowObject
+type OAuthFlow struct {
+	// Type is one of the OAuthFlow constants, like OAuthFlowAuthorizationCode.
+	Type string
+	// AuthorizationURL is required for the implicit and authorizationCode flows.
+	AuthorizationURL string
+	// TokenURL is required for the password, clientCredentials, and authorizationCode flows.
+	TokenURL string
+	// RefreshURL is optional, and used for obtaining refresh tokens.
+	RefreshURL string
+	// Scopes maps the names of the scopes available for the flow to their description.
+	Scopes map[string]string
+}
+
+// Panic if the flow type is unknown, or it is missing a URL its type needs.
+func (flow OAuthFlow) validate() {
+	var needsAuthURL, needsTokenURL bool
+	switch flow.Type {
+	case OAuthFlowAuthorizationCode:
+		needsAuthURL, needsTokenURL = true, true
+	case OAuthFlowImplicit:
+		needsAuthURL = true
+	case OAuthFlowClientCredentials, OAuthFlowPassword:
+		needsTokenURL = true
+	default:
+		panic(fmt.Sprintf("invalid OAuth2 flow type %q", flow.Type))
+	}
+	if needsAuthURL && flow.AuthorizationURL == "" {
+		panic(fmt.Sprintf("OAuth2 %s flow requires an AuthorizationURL", flow.Type))
+	}
+	if needsTokenURL && flow.TokenURL == "" {
+		panic(fmt.Sprintf("OAuth2 %s flow requires a TokenURL", flow.Type))
+	}
+}
+
+// Return the flow's scope names in sorted order.
+func (flow OAuthFlow) sortedScopes() []string {
+	names := make([]string, 0, len(flow.Scopes))
+	for name := range flow.Scopes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SecurityScheme describes a way of authorizing requests, added with Sashay#AddSecurityScheme.
+// Only the fields relevant to the Type are used.
+// See https://swagger.io/specification/#securitySchemeObject
+type SecurityScheme struct {
+	// Type is one of "http", "apiKey", "oauth2", "openIdConnect", or "mutualTLS" (OpenAPI 3.1 only).
+	Type string
+	// Description is an optional description of the scheme, with Markdown support.
+	Description string
+	// Scheme is the HTTP authorization scheme for the http type, like "basic", "bearer", or "digest".
+	Scheme string
+	// BearerFormat is an optional hint of how bearer tokens are formatted, like "JWT".
+	BearerFormat string
+	// In is where the API key is for the apiKey type: "query", "header", or "cookie".
+	In string
+	// Name is the name of the header, query parameter, or cookie for the apiKey type.
+	Name string
+	// Flows are the OAuth2 flows for the oauth2 type.
+	Flows []OAuthFlow
+	// OpenIDConnectURL is the OpenID Connect discovery URL for the openIdConnect type.
+	OpenIDConnectURL string
+}
+
+// Panic if the scheme with the given ID is missing any fields its type needs.
+func (ss SecurityScheme) validate(id string) {
+	require := func(field, value string) {
+		if value == "" {
+			panic(fmt.Sprintf("security scheme %q of type %s requires a %s", id, ss.Type, field))
+		}
+	}
+	switch ss.Type {
+	case "http":
+		require("Scheme", ss.Scheme)
+	case "apiKey":
+		require("Name", ss.Name)
+		if !containsString([]string{"query", "header", "cookie"}, ss.In) {
+			panic(fmt.Sprintf("security scheme %q has invalid In %q, must be query, header, or cookie", id, ss.In))
+		}
+	case "oauth2":
+		if len(ss.Flows) == 0 {
+			panic(fmt.Sprintf("security scheme %q of type oauth2 requires Flows", id))
+		}
+		for i, flow := range ss.Flows {
+			flow.validate()
+			for _, other := range ss.Flows[:i] {
+				if other.Type == flow.Type {
+					panic(fmt.Sprintf("OAuth2 security has more than one %s flow", flow.Type))
+				}
+			}
+		}
+	case "openIdConnect":
+		require("OpenIDConnectURL", ss.OpenIDConnectURL)
+	case "mutualTLS":
+	default:
+		panic(fmt.Sprintf("security scheme %q has invalid type %q", id, ss.Type))
+	}
+}
+
+type swaggerSecurity struct {
+	id     string
+	scheme SecurityScheme
+}
+
+func (ss swaggerSecurity) ID() string {
+	return ss.id
+}
+
+// Fields returns the scalar fields of the scheme which are written into its YAML.
+func (ss swaggerSecurity) Fields() ObjectFields {
+	of := ObjectFields{"type": ss.scheme.Type}
+	set := func(k, v string) {
+		if v != "" {
+			of[k] = v
+		}
+	}
+	set("description", ss.scheme.Description)
+	set("scheme", ss.scheme.Scheme)
+	set("bearerFormat", ss.scheme.BearerFormat)
+	set("in", ss.scheme.In)
+	set("name", ss.scheme.Name)
+	set("openIdConnectUrl", ss.scheme.OpenIDConnectURL)
+	return of
+}
+
+// Return true if the scope can be required for the security scheme.
+// Only OAuth2 schemes declare their scopes; other schemes can use any scope
+// (like OpenID Connect, whose scopes are found through discovery).
+func (ss swaggerSecurity) hasScope(scope string) bool {
+	if ss.scheme.Type != "oauth2" {
+		return true
+	}
+	for _, flow := range ss.scheme.Flows {
+		if _, ok := flow.Scopes[scope]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SecurityRequirement maps the IDs of security schemes to the scopes they require,
+// like {"bearerAuth": []} or {"oauth": ["pets:read"]}.
+// All of the schemes in a SecurityRequirement must be satisfied (AND),
+// while an Operation is authorized if any of its SecurityRequirements are satisfied (OR).
+// An empty SecurityRequirement means no authorization is needed,
+// which is useful for making security optional.
+// See https://swagger.io/specification/#securityRequirementObject
+type SecurityRequirement map[string][]string
+
+// Return the requirement's scheme IDs in sorted order.
+func (sr SecurityRequirement) sortedIDs() []string {
+	ids := make([]string, 0, len(sr))
+	for id := range sr {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Write the security requirements as a YAML list at indent.
+// Panics if a requirement refers to a security scheme that was not added to the Sashay.
+func (b *baseBuilder) writeSecurityRequirements(indent int, reqs []SecurityRequirement) {
+	if len(reqs) == 0 {
+		b.writeLn(indent, "security: []")
+		return
+	}
+	b.writeLn(indent, "security:")
+	for _, req := range reqs {
+		if len(req) == 0 {
+			b.writeLn(indent+1, "- {}")
+			continue
+		}
+		prefix := "- "
+		for _, id := range req.sortedIDs() {
+			sec, ok := b.swagger.security(id)
+			if !ok {
+				panic(fmt.Sprintf("security scheme %q is not defined, add it to the Sashay before requiring it", id))
+			}
+			scopes := req[id]
+			for _, scope := range scopes {
+				if !sec.hasScope(scope) {
+					panic(fmt.Sprintf("security scheme %q does not define scope %q", id, scope))
+				}
+			}
+			if len(scopes) == 0 {
+				b.writeLn(indent+1, "%s%s: []", prefix, id)
+			} else {
+				b.writeLn(indent+1, `%s%s: ["%s"]`, prefix, id, strings.Join(scopes, `", "`))
+			}
+			prefix = "  "
+		}
+	}
+}
+
+// Return the security scheme with the given ID, and true if it has been added.
+func (sa *Sashay) security(id string) (swaggerSecurity, bool) {
+	for _, sec := range sa.securities {
+		if sec.ID() == id {
+			return sec, true
+		}
+	}
+	return swaggerSecurity{}, false
+}
+
+// Return the document-wide security requirements,
+// where any of the added security schemes can be used.
+func (sa *Sashay) globalSecurity() []SecurityRequirement {
+	reqs := make([]SecurityRequirement, 0, len(sa.securities))
+	for _, sec := range sa.securities {
+		reqs = append(reqs, SecurityRequirement{sec.ID(): {}})
+	}
+	return reqs
+}